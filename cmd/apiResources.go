@@ -4,33 +4,100 @@ Copyright © 2024 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
+	"slices"
+	"strings"
 
-	"github.com/bverschueren/in2un/pkg/reader"
 	log "github.com/sirupsen/logrus"
+
+	"github.com/bverschueren/in2un/pkg/reader"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"k8s.io/cli-runtime/pkg/printers"
+)
+
+var apiResourcesSortBy string
+
+// api-resources has its own output flags, the formats and defaults of get do not apply
+var (
+	apiResourcesOutput    string
+	apiResourcesNoHeaders bool
 )
 
 // apiResourcesCmd represents the apiResources command
 var apiResourcesCmd = &cobra.Command{
-	Use:    "api-resources",
-	Args:   cobra.MaximumNArgs(0),
-	Short:  "(Experimental) List available resources in an Insights archive.",
-	Hidden: true,
-	Run: func(cmd *cobra.Command, args []string) {
-		ir, err := reader.NewInsightsReader(viper.GetString("active"))
+	Use:   "api-resources",
+	Args:  cobra.MaximumNArgs(0),
+	Short: "List the resource types in an Insights archive with the number of objects of each type.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ir, err := reader.NewInsightsReader(viper.GetString("active"), reader.WithIndexCache(ConfigDir))
+		if err != nil {
+			return err
+		}
+		defer ir.Close()
+		found, warnings, err := ir.ReadAPIResources()
 		if err != nil {
-			log.Fatal(err)
+			return err
+		}
+		for _, warning := range warnings {
+			log.Info(warning)
 		}
-		found := ir.ReadResourceTypes()
-		fmt.Printf("NAME\n")
-		for f := range *found {
-			fmt.Printf("%s\n", f)
+		switch apiResourcesSortBy {
+		case "name", "":
+		case "kind":
+			slices.SortStableFunc(found, func(a, b reader.APIResource) int { return strings.Compare(a.Kind, b.Kind) })
+		default:
+			return fmt.Errorf("--sort-by accepts only name or kind")
 		}
+		return printAPIResources(found, apiResourcesOutput)
 	},
 }
 
+func printAPIResources(resources []reader.APIResource, format string) error {
+	switch format {
+	case "json":
+		if resources == nil {
+			resources = []reader.APIResource{}
+		}
+		raw, err := json.MarshalIndent(resources, "", "    ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(raw))
+		return nil
+	case "name":
+		for _, resource := range resources {
+			fmt.Println(resource.Name)
+		}
+		return nil
+	case "wide", "table", "":
+	default:
+		return fmt.Errorf("unable to match a printer suitable for the output format %q, allowed formats are: json,name,wide", format)
+	}
+	w := printers.GetNewTabWriter(os.Stdout)
+	defer w.Flush()
+	if !apiResourcesNoHeaders {
+		fmt.Fprint(w, "NAME\tSHORTNAMES\tAPIVERSION\tNAMESPACED\tKIND\tCOUNT")
+		if format == "wide" {
+			fmt.Fprint(w, "\tSOURCES")
+		}
+		fmt.Fprintln(w)
+	}
+	for _, resource := range resources {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%t\t%s\t%d", resource.Name, strings.Join(resource.ShortNames, ","), resource.APIVersion, resource.Namespaced, resource.Kind, resource.Count)
+		if format == "wide" {
+			fmt.Fprintf(w, "\t%s", strings.Join(resource.Sources, ","))
+		}
+		fmt.Fprintln(w)
+	}
+	return nil
+}
+
 func init() {
 	InsightsCmd.AddCommand(apiResourcesCmd)
+	apiResourcesCmd.Flags().StringVarP(&apiResourcesOutput, "output", "o", "", "Output format. One of: (json, name, wide).")
+	apiResourcesCmd.Flags().StringVar(&apiResourcesSortBy, "sort-by", "name", "If non-empty, sort list of resources using specified field. One of (name, kind).")
+	apiResourcesCmd.Flags().BoolVar(&apiResourcesNoHeaders, "no-headers", false, "When using the default or wide output format, don't print headers.")
 }
@@ -0,0 +1,113 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/bverschueren/in2un/pkg/describe"
+	"github.com/bverschueren/in2un/pkg/filter"
+	"github.com/bverschueren/in2un/pkg/output"
+	"github.com/bverschueren/in2un/pkg/reader"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var describeCmd = &cobra.Command{
+	Use:  "describe (TYPE[,TYPE...] [NAME...] | TYPE/NAME ...)",
+	Args: cobra.MinimumNArgs(1),
+	PreRun: func(cmd *cobra.Command, args []string) {
+		if AllNamespaces {
+			Namespace = reader.AllNamespaceValue
+		}
+	},
+	Short: "Show details of resources in insights data, including their related events.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		refs, err := processArgs(args)
+		if err != nil {
+			return err
+		}
+		ir, err := reader.NewInsightsReader(viper.GetString("active"), reader.WithIndexCache(ConfigDir))
+		if err != nil {
+			return err
+		}
+		defer ir.Close()
+		found, warnings, err := ir.ReadResources(refs, Namespace, "", "")
+		if err != nil {
+			return err
+		}
+		found, err = filter.ByLabels(found, Selector)
+		if err != nil {
+			return err
+		}
+		output.SortByName(found)
+		reportWarnings("", found, warnings)
+		if len(found.Items) == 0 {
+			fmt.Fprintln(os.Stderr, "No resources found")
+			return nil
+		}
+		events := newEventCache(ir)
+		for i := range found.Items {
+			if i > 0 {
+				fmt.Fprintln(os.Stdout)
+			}
+			related, err := events.forObject(&found.Items[i])
+			if err != nil {
+				return err
+			}
+			if err := describe.Describe(os.Stdout, &found.Items[i], related, ir.ReferenceTime()); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+// eventCache reads the events of each namespace once when describing multiple resources
+type eventCache struct {
+	ir     *reader.InsightsReader
+	events map[string][]unstructured.Unstructured
+}
+
+func newEventCache(ir *reader.InsightsReader) *eventCache {
+	return &eventCache{ir: ir, events: make(map[string][]unstructured.Unstructured)}
+}
+
+// return the events that could relate to obj: those of its namespace or, for cluster scoped resources, all events
+func (c *eventCache) forObject(obj *unstructured.Unstructured) ([]unstructured.Unstructured, error) {
+	namespace := obj.GetNamespace()
+	if namespace == "" {
+		namespace = reader.AllNamespaceValue
+	}
+	if events, ok := c.events[namespace]; ok {
+		return events, nil
+	}
+	found, warnings, err := c.ir.ReadEvents(namespace)
+	if err != nil {
+		return nil, err
+	}
+	reportWarnings("", found, warnings)
+	c.events[namespace] = found.Items
+	return found.Items, nil
+}
+
+func init() {
+	InsightsCmd.AddCommand(describeCmd)
+	describeCmd.Flags().BoolVarP(&AllNamespaces, "all-namespaces", "A", false, "Set the namespace scope for this CLI request to all namespaces")
+	describeCmd.Flags().StringVarP(&Selector, "selector", "l", "", "Selector (label query) to filter on, supports '=', '==', '!=', 'in', 'notin' and '!key' (e.g. -l key1=value1,key2=value2,'!key3'). Matching objects must satisfy all of the specified label constraints.")
+}
@@ -0,0 +1,103 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bverschueren/in2un/pkg/describe"
+	"github.com/bverschueren/in2un/pkg/deserializer"
+	"github.com/bverschueren/in2un/pkg/filter"
+	"github.com/bverschueren/in2un/pkg/output"
+	"github.com/bverschueren/in2un/pkg/reader"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var (
+	eventsFor   string
+	eventsTypes []string
+	// events has its own output flags rather than sharing those of get
+	eventsOutput    string
+	eventsNoHeaders bool
+)
+
+var eventsCmd = &cobra.Command{
+	Use:  "events",
+	Args: cobra.NoArgs,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		if AllNamespaces {
+			Namespace = reader.AllNamespaceValue
+		}
+	},
+	Short: "List events from insights data, sorted by the time they were last seen.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ir, err := reader.NewInsightsReader(viper.GetString("active"), reader.WithIndexCache(ConfigDir))
+		if err != nil {
+			return err
+		}
+		defer ir.Close()
+		found, warnings, err := ir.ReadEvents(Namespace)
+		if err != nil {
+			return err
+		}
+		if eventsFor != "" {
+			obj, err := eventsForObject(eventsFor, Namespace)
+			if err != nil {
+				return err
+			}
+			found.Items = describe.RelatedEvents(obj, found.Items)
+		}
+		found, err = filter.ByEventTypes(found, eventsTypes)
+		if err != nil {
+			return err
+		}
+		if err := output.SortBy(found, "{.lastTimestamp}"); err != nil {
+			return err
+		}
+		return handleOutput(eventsOutput, eventsNoHeaders, found, warnings, false, ir.ReferenceTime())
+	},
+}
+
+// the object to list events for, given in resource/name form as for kubectl events --for
+func eventsForObject(resource, namespace string) (*unstructured.Unstructured, error) {
+	resourceGroup, resourceName, ok := strings.Cut(resource, "/")
+	if !ok || resourceGroup == "" || resourceName == "" {
+		return nil, fmt.Errorf("invalid --for '%s', expected the resource/name form (e.g. pod/etcd-master-0)", resource)
+	}
+	kind := resourceGroup
+	if gvk, ok := deserializer.KnownType(Unalias(resourceGroup)); ok {
+		kind = gvk.Kind
+	}
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	obj.SetKind(kind)
+	obj.SetName(resourceName)
+	if namespace != reader.AllNamespaceValue {
+		obj.SetNamespace(namespace)
+	}
+	return obj, nil
+}
+
+func init() {
+	InsightsCmd.AddCommand(eventsCmd)
+	eventsCmd.Flags().BoolVarP(&AllNamespaces, "all-namespaces", "A", false, "Set the namespace scope for this CLI request to all namespaces")
+	eventsCmd.Flags().StringVar(&eventsFor, "for", "", "Filter events to only those pertaining to the specified resource (e.g. --for pod/etcd-master-0).")
+	eventsCmd.Flags().StringSliceVar(&eventsTypes, "types", nil, "Output only events of given types (e.g. --types Warning or --types Normal,Warning).")
+	eventsCmd.Flags().StringVarP(&eventsOutput, "output", "o", "table", "Output format. One of: (json, yaml, name, custom-columns=, custom-columns-file=, jsonpath=, jsonpath-file=, go-template=, go-template-file=, template=, templatefile=).")
+	eventsCmd.Flags().BoolVar(&eventsNoHeaders, "no-headers", false, "When using the default or custom-column output format, don't print headers.")
+}
@@ -16,23 +16,34 @@ limitations under the License.
 package cmd
 
 import (
+	"fmt"
 	"os"
+	"slices"
+	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
 	"github.com/bverschueren/in2un/pkg/deserializer"
+	"github.com/bverschueren/in2un/pkg/filter"
+	"github.com/bverschueren/in2un/pkg/output"
 	"github.com/bverschueren/in2un/pkg/reader"
+	"github.com/bverschueren/in2un/pkg/table"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/cli-runtime/pkg/printers"
 	"k8s.io/client-go/kubernetes/scheme"
 )
 
-var OverrideApiVersion, OverrideKind, Output string
+var (
+	OverrideApiVersion, OverrideKind, Output, Template, Selector, FieldSelector, SortBy string
+	NoHeaders, AllowMissingTemplateKeys                                                 bool
+)
 
 var getCmd = &cobra.Command{
-	Use:  "get",
+	Use:  "get (TYPE[,TYPE...] [NAME...] | TYPE/NAME ...)",
 	Args: cobra.MinimumNArgs(1),
 	PreRun: func(cmd *cobra.Command, args []string) {
 		if AllNamespaces {
@@ -41,65 +52,206 @@ var getCmd = &cobra.Command{
 	},
 
 	Short: "Parse Insights data as generic unstructured (https://pkg.go.dev/k8s.io/apimachinery/pkg/apis/meta/v1/unstructured) data.",
-	Run: func(cmd *cobra.Command, args []string) {
-		resourceGroup, resourceName := processArgs(args)
-		ir, err := reader.NewInsightsReader(viper.GetString("active"))
+	RunE: func(cmd *cobra.Command, args []string) error {
+		refs, err := processArgs(args)
+		if err != nil {
+			return err
+		}
+		ir, err := reader.NewInsightsReader(viper.GetString("active"), reader.WithIndexCache(ConfigDir))
+		if err != nil {
+			return err
+		}
+		defer ir.Close()
+		found, warnings, err := ir.ReadResources(refs, Namespace, OverrideApiVersion, OverrideKind)
+		if err != nil {
+			return err
+		}
+		found, err = filter.ByLabels(found, Selector)
+		if err != nil {
+			return err
+		}
+		found, err = filter.ByFields(found, FieldSelector)
 		if err != nil {
-			log.Fatal(err)
+			return err
 		}
-		found := ir.ReadResource(resourceGroup, resourceName, Namespace, OverrideApiVersion, OverrideKind)
-		handleOutput(Output, found)
+		if SortBy != "" {
+			if err := output.SortBy(found, SortBy); err != nil {
+				return err
+			}
+		} else {
+			output.SortByName(found)
+		}
+		return handleOutput(Output, NoHeaders, found, warnings, len(refs) == 1 && refs[0].ResourceName != "", ir.ReferenceTime())
 	},
 }
 
-func handleOutput(format string, obj *unstructured.UnstructuredList) {
+// print obj in the given output format, ages in tables are relative to now
+func handleOutput(format string, noHeaders bool, obj *unstructured.UnstructuredList, warnings reader.Warnings, singleItem bool, now time.Time) error {
 	if hasDummyFields(obj) {
-		log.Warning("Hint: use --api-version and --kind to override dummy values for missing fields in insights archives")
+		log.Warning("Hint: use --api-version and --kind to override dummy values for resource types unknown to in2un")
+	}
+	// kubectl assumes a go-template when only --template is given
+	if format == "table" && Template != "" {
+		format = "go-template"
 	}
-	var printr printers.ResourcePrinter
+	reportWarnings(format, obj, warnings)
 	switch format {
+	case "table", "":
+		return printTables(obj, false, noHeaders, now)
+	case "wide":
+		return printTables(obj, true, noHeaders, now)
+	}
+	printr, err := newPrinter(format, noHeaders)
+	if err != nil {
+		return err
+	}
+	// as kubectl does, templates for a single named resource apply to the resource rather than the list
+	if name, _, _ := strings.Cut(format, "="); slices.Contains(templateFormats, name) && singleItem && len(obj.Items) == 1 {
+		return printr.PrintObj(&obj.Items[0], os.Stdout)
+	}
+	return printr.PrintObj(obj, os.Stdout)
+}
+
+// formats for which the user provides the template
+var templateFormats = []string{"jsonpath", "jsonpath-file", "go-template", "go-template-file", "template", "templatefile"}
+
+// return the printer for an output format, e.g. 'json', 'jsonpath={.items[*].metadata.name}' or 'custom-columns-file=columns.txt'
+func newPrinter(format string, noHeaders bool) (printers.ResourcePrinter, error) {
+	name, arg, hasArg := strings.Cut(format, "=")
+	if slices.Contains(templateFormats, name) {
+		return newTemplatePrinter(name, arg, hasArg)
+	}
+	switch name {
 	case "yaml":
-		printr = printers.NewTypeSetter(scheme.Scheme).ToPrinter(&printers.YAMLPrinter{})
-		if err := printr.PrintObj(obj, os.Stdout); err != nil {
-			panic(err.Error())
-		}
+		return printers.NewTypeSetter(scheme.Scheme).ToPrinter(&printers.YAMLPrinter{}), nil
 	case "json":
-		printr = printers.NewTypeSetter(scheme.Scheme).ToPrinter(&printers.JSONPrinter{})
-		if err := printr.PrintObj(obj, os.Stdout); err != nil {
-			panic(err.Error())
-		}
+		return printers.NewTypeSetter(scheme.Scheme).ToPrinter(&printers.JSONPrinter{}), nil
 	case "name":
-		printr = printers.NewTypeSetter(scheme.Scheme).ToPrinter(&printers.NamePrinter{})
-		if err := printr.PrintObj(obj, os.Stdout); err != nil {
-			panic(err.Error())
+		return printers.NewTypeSetter(scheme.Scheme).ToPrinter(&printers.NamePrinter{}), nil
+	case "custom-columns":
+		return output.NewCustomColumnsPrinter(arg, noHeaders)
+	case "custom-columns-file":
+		file, err := os.Open(arg)
+		if err != nil {
+			return nil, fmt.Errorf("error reading template %s: %w", arg, err)
+		}
+		defer file.Close()
+		return output.NewCustomColumnsPrinterFromTemplate(file, noHeaders)
+	}
+	return nil, fmt.Errorf("unable to match a printer suitable for the output format %q, allowed formats are: %s", format, strings.Join(outputFormats, ","))
+}
+
+// return a jsonpath or go-template printer for a template given inline, in a file or with --template
+func newTemplatePrinter(name, arg string, hasArg bool) (printers.ResourcePrinter, error) {
+	tmpl := Template
+	if hasArg {
+		tmpl = arg
+	}
+	if tmpl == "" {
+		return nil, fmt.Errorf("%s format specified but no template given", name)
+	}
+	if strings.HasSuffix(name, "file") {
+		raw, err := os.ReadFile(tmpl)
+		if err != nil {
+			return nil, fmt.Errorf("error reading template %s: %w", tmpl, err)
+		}
+		tmpl = string(raw)
+	}
+	if strings.HasPrefix(name, "jsonpath") {
+		printr, err := printers.NewJSONPathPrinter(tmpl)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing jsonpath %s: %w", tmpl, err)
+		}
+		printr.AllowMissingKeys(AllowMissingTemplateKeys)
+		return printr, nil
+	}
+	printr, err := printers.NewGoTemplatePrinter([]byte(tmpl))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing template %s: %w", tmpl, err)
+	}
+	printr.AllowMissingKeys(AllowMissingTemplateKeys)
+	return printr, nil
+}
+
+var outputFormats = []string{"custom-columns", "custom-columns-file", "go-template", "go-template-file", "json", "jsonpath", "jsonpath-file", "name", "template", "templatefile", "wide", "yaml"}
+
+// print a table per kind with ages relative to now, as kubectl does when getting multiple resource types
+func printTables(obj *unstructured.UnstructuredList, wide, noHeaders bool, now time.Time) error {
+	if len(obj.Items) == 0 {
+		fmt.Fprintln(os.Stderr, "No resources found")
+		return nil
+	}
+	var kinds []schema.GroupKind
+	sections := make(map[schema.GroupKind][]unstructured.Unstructured)
+	for _, item := range obj.Items {
+		kind := item.GroupVersionKind().GroupKind()
+		if _, ok := sections[kind]; !ok {
+			kinds = append(kinds, kind)
+		}
+		sections[kind] = append(sections[kind], item)
+	}
+	for i, kind := range kinds {
+		if i > 0 {
+			fmt.Fprintln(os.Stdout)
+		}
+		options := printers.PrintOptions{
+			WithKind:  len(kinds) > 1,
+			Kind:      kind,
+			Wide:      wide,
+			NoHeaders: noHeaders,
 		}
-	default: //table printer
-		// test the first objects for namespaceness
-		// once/if we support multi-resource get, we should do this more accurately
-		options := printers.PrintOptions{}
-		if obj.Items[0].GetNamespace() != "" {
-			options.WithNamespace = true
+		for _, item := range sections[kind] {
+			if item.GetNamespace() != "" {
+				options.WithNamespace = true
+				break
+			}
 		}
-		printr = printers.NewTypeSetter(scheme.Scheme).ToPrinter(printers.NewTablePrinter(options))
-		if err := printr.PrintObj(obj, os.Stdout); err != nil {
-			panic(err.Error())
+		if err := printers.NewTablePrinter(options).PrintObj(table.ToTable(sections[kind], now), os.Stdout); err != nil {
+			return err
 		}
 	}
+	return nil
+}
+
+// warn the output is partial and, for structured output, include the skipped files in the list itself.
+// obj is nil for output which has no list to hold them.
+func reportWarnings(format string, obj *unstructured.UnstructuredList, warnings reader.Warnings) {
+	if len(warnings) == 0 {
+		return
+	}
+	for _, warning := range warnings {
+		log.Info(warning)
+	}
+	if obj == nil {
+		log.Warningf("Output is partial: %d file(s) in the insights archive could not be parsed (use --loglevel=info for details)", len(warnings))
+		return
+	}
+	log.Warningf("Output is partial: %d file(s) in the insights archive could not be parsed (use --loglevel=info or -o json for details)", len(warnings))
+	if format == "json" || format == "yaml" {
+		obj.Object["warnings"] = warnings.Unstructured()
+	}
 }
 
 func hasDummyFields(obj *unstructured.UnstructuredList) bool { //TODO: generic warning loop interface
-	if len(obj.Items) > 0 {
-		return obj.Items[0].Object["apiVersion"] == deserializer.MissingTypeMetaFieldValue || obj.Items[0].Object["kind"] == deserializer.MissingTypeMetaFieldValue
-	} else {
-		return false
+	for _, item := range obj.Items {
+		if item.Object["apiVersion"] == deserializer.MissingTypeMetaFieldValue || item.Object["kind"] == deserializer.MissingTypeMetaFieldValue {
+			return true
+		}
 	}
+	return false
 }
 
 func init() {
 	InsightsCmd.AddCommand(getCmd)
 	//getCmd.PersistentFlags().BoolVarP(&AllNamespaces, "all-namespaces", "A", false, "Set the namespace scope for this CLI request to all namespaces")
 	getCmd.Flags().BoolVarP(&AllNamespaces, "all-namespaces", "A", false, "Set the namespace scope for this CLI request to all namespaces")
-	getCmd.Flags().StringVarP(&Output, "output", "o", "table", "Output format. One of: (json, yaml, name).")
-	getCmd.Flags().StringVar(&OverrideApiVersion, "api-version", "", "Override the apiVersion for the specified resource. By default the apiVersion is trimmed off resource in insights data")
-	getCmd.Flags().StringVar(&OverrideKind, "kind", "", "Override the apiVersion for the specified resource. By default the apiVersion is trimmed off resource in insights data")
+	getCmd.Flags().StringVarP(&Output, "output", "o", "table", "Output format. One of: (json, yaml, name, wide, custom-columns=, custom-columns-file=, jsonpath=, jsonpath-file=, go-template=, go-template-file=, template=, templatefile=).")
+	getCmd.Flags().StringVar(&Template, "template", "", "Template string or path to template file to use when -o=go-template, -o=go-template-file, -o=jsonpath or -o=jsonpath-file.")
+	getCmd.Flags().BoolVar(&NoHeaders, "no-headers", false, "When using the default or custom-column output format, don't print headers.")
+	getCmd.Flags().BoolVar(&AllowMissingTemplateKeys, "allow-missing-template-keys", true, "If true, ignore any errors in templates when a field or map key is missing in the template. Only applies to jsonpath and go-template output formats.")
+	getCmd.Flags().StringVarP(&Selector, "selector", "l", "", "Selector (label query) to filter on, supports '=', '==', '!=', 'in', 'notin' and '!key' (e.g. -l key1=value1,key2=value2,'!key3'). Matching objects must satisfy all of the specified label constraints.")
+	getCmd.Flags().StringVar(&FieldSelector, "field-selector", "", "Selector (field query) to filter on, supports '=', '==', and '!=' on any dotted path in the resource (e.g. --field-selector status.phase!=Running,spec.nodeName=master-0).")
+	getCmd.Flags().StringVar(&SortBy, "sort-by", "", "If non-empty, sort list types using this field specification. The field specification is expressed as a JSONPath expression (e.g. '{.metadata.name}'). By default resources are sorted by namespace and name.")
+	getCmd.Flags().StringVar(&OverrideApiVersion, "api-version", "", "Override the apiVersion for the specified resource. By default the apiVersion is derived from the resource's location in the insights archive")
+	getCmd.Flags().StringVar(&OverrideKind, "kind", "", "Override the kind for the specified resource. By default the kind is derived from the resource's location in the insights archive")
 }
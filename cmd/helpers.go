@@ -21,45 +21,50 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/bverschueren/in2un/pkg/helpers"
+	"github.com/bverschueren/in2un/pkg/reader"
 	"github.com/spf13/viper"
 
 	log "github.com/sirupsen/logrus"
 )
 
+var errResourceNameForm = fmt.Errorf("there is no need to specify a resource type as a separate argument when passing arguments in resource/name form (e.g. 'in2un get resource/<resource_name>' instead of 'in2un get resource resource/<resource_name>')")
+
 // follow kubectl logic and expect args to be either:
-// a resource as single argument: "<recource-type>"
-// a resource and its name as a single argument seperated by a slash: "<recource-type>/<resource-name>"
-// a resource and its name as sequential arguments: "<recource-type>/<resource-name>"
-func processArgs(args []string) (string, string) {
-	var resourceGroup, resourceName string
-	if len(args) == 1 && strings.Contains(args[0], "/") {
-		parts := strings.Split(args[0], "/")
-		resourceGroup = parts[0]
-		resourceName = parts[1]
-	} else {
-		resourceGroup = args[0]
-		if len(args) > 1 {
-			resourceName = args[1]
+// one or more comma separated resource types as a single argument: "<resource-type>[,<resource-type>...]"
+// resource types followed by one or more names: "<resource-type>[,<resource-type>...] <resource-name> [<resource-name>...]"
+// one or more resources and their name seperated by a slash: "<resource-type>/<resource-name> [<resource-type>/<resource-name>...]"
+func processArgs(args []string) ([]reader.ResourceRef, error) {
+	var refs []reader.ResourceRef
+	if strings.Contains(args[0], "/") {
+		for _, arg := range args {
+			resourceGroup, resourceName, ok := strings.Cut(arg, "/")
+			if !ok || resourceGroup == "" || resourceName == "" {
+				return nil, errResourceNameForm
+			}
+			refs = append(refs, reader.ResourceRef{ResourceGroup: Unalias(resourceGroup), ResourceName: resourceName})
+		}
+		return refs, nil
+	}
+	for _, resourceGroup := range strings.Split(args[0], ",") {
+		if resourceGroup == "" {
+			return nil, fmt.Errorf("invalid resource type '%s'", args[0])
+		}
+		if len(args) == 1 {
+			refs = append(refs, reader.ResourceRef{ResourceGroup: Unalias(resourceGroup)})
+		}
+		for _, resourceName := range args[1:] {
+			if strings.Contains(resourceName, "/") {
+				return nil, errResourceNameForm
+			}
+			refs = append(refs, reader.ResourceRef{ResourceGroup: Unalias(resourceGroup), ResourceName: resourceName})
 		}
 	}
-	resourceGroup = Unalias(resourceGroup)
-	return resourceGroup, resourceName
+	return refs, nil
 }
 
 func Unalias(alias string) string {
-	log.Debug("Using static alias map as best effort")
-	aliases := map[string]string{
-		"mc":             "machineconfig",
-		"mcp":            "machineconfigpool",
-		"cm":             "configmap",
-		"co":             "clusteroperator",
-		"ns":             "namespace",
-		"clusterversion": "version",
-	}
-	if unalias, ok := aliases[alias]; ok {
-		return unalias
-	}
-	return alias
+	return helpers.Unalias(alias)
 }
 
 func initConfig() {
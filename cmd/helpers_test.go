@@ -16,41 +16,69 @@ limitations under the License.
 package cmd
 
 import (
+	"reflect"
 	"testing"
+
+	"github.com/bverschueren/in2un/pkg/reader"
 )
 
 func TestProcessArgs(t *testing.T) {
 	tests := []struct {
-		name                        string
-		in                          []string
-		resourceGroup, resourceName string
+		name        string
+		in          []string
+		expected    []reader.ResourceRef
+		expectedErr bool
 	}{
 		{
-			name:          "single argument resourcegroup",
-			in:            []string{"pod"},
-			resourceGroup: "pod",
-			resourceName:  "",
+			name:     "single argument resourcegroup",
+			in:       []string{"pod"},
+			expected: []reader.ResourceRef{{ResourceGroup: "pod"}},
+		},
+		{
+			name:     "single argument separated by a slash",
+			in:       []string{"pod/name"},
+			expected: []reader.ResourceRef{{ResourceGroup: "pod", ResourceName: "name"}},
+		},
+		{
+			name:     "sequential argument resourcegroup/resourcename",
+			in:       []string{"pod", "name"},
+			expected: []reader.ResourceRef{{ResourceGroup: "pod", ResourceName: "name"}},
+		},
+		{
+			name:     "comma separated resourcegroups",
+			in:       []string{"pods,cm,co"},
+			expected: []reader.ResourceRef{{ResourceGroup: "pods"}, {ResourceGroup: "configmap"}, {ResourceGroup: "clusteroperator"}},
 		},
 		{
-			name:          "single argument separated by a slash",
-			in:            []string{"pod/name"},
-			resourceGroup: "pod",
-			resourceName:  "name",
+			name:     "comma separated resourcegroups with multiple names",
+			in:       []string{"pod,cm", "a", "b"},
+			expected: []reader.ResourceRef{{ResourceGroup: "pod", ResourceName: "a"}, {ResourceGroup: "pod", ResourceName: "b"}, {ResourceGroup: "configmap", ResourceName: "a"}, {ResourceGroup: "configmap", ResourceName: "b"}},
 		},
 		{
-			name:          "sequential argument resourcegroup/resourcename",
-			in:            []string{"pod", "name"},
-			resourceGroup: "pod",
-			resourceName:  "name",
+			name:     "multiple arguments separated by a slash",
+			in:       []string{"pod/a", "co/network"},
+			expected: []reader.ResourceRef{{ResourceGroup: "pod", ResourceName: "a"}, {ResourceGroup: "clusteroperator", ResourceName: "network"}},
+		},
+		{
+			name:        "mixed resourcegroup and resource/name arguments",
+			in:          []string{"pod/a", "b"},
+			expectedErr: true,
+		},
+		{
+			name:        "resourcegroup followed by resource/name argument",
+			in:          []string{"pod", "co/network"},
+			expectedErr: true,
 		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			resourceGroup, resourceName := processArgs(tc.in)
-
-			if (resourceGroup != tc.resourceGroup) || (resourceName != tc.resourceName) {
-				t.Fatalf("Expected: resourceName=%s, resourceGroup=%s got: resourceName=%s, resourceGroup=%s", tc.resourceName, tc.resourceGroup, resourceName, resourceGroup)
+			got, err := processArgs(tc.in)
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("Expected error: %t, got: %v", tc.expectedErr, err)
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Fatalf("Expected: %v, got: %v", tc.expected, got)
 			}
 		})
 	}
@@ -16,38 +16,239 @@ limitations under the License.
 package cmd
 
 import (
+	"fmt"
 	"io"
 	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 
-	"github.com/bverschueren/in2un/pkg/reader"
 	log "github.com/sirupsen/logrus"
+
+	"github.com/bverschueren/in2un/pkg/logs"
+	"github.com/bverschueren/in2un/pkg/output"
+	"github.com/bverschueren/in2un/pkg/reader"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 var (
 	logsCmd = &cobra.Command{
-		Use:   "logs",
+		Use:   "logs [-c CONTAINER] (POD | TYPE/NAME)",
 		Args:  cobra.MinimumNArgs(1),
 		Short: "Return raw log lines from insights data.",
-		Run: func(cmd *cobra.Command, args []string) {
-			resourceGroup := "pod" // TODO: implement logging for <resource-type>/<resource-name>
-			resourceName := args[0]
-			ir, err := reader.NewInsightsReader(viper.GetString("active"))
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ir, err := reader.NewInsightsReader(viper.GetString("active"), reader.WithIndexCache(ConfigDir))
 			if err != nil {
-				log.Fatal(err)
+				return err
 			}
-			found := ir.ReadLog(resourceGroup, resourceName, Namespace, containerName, previous)
-			io.Copy(os.Stdout, found)
+			defer ir.Close()
+			filter, err := newLogFilter(ir.GatherTime())
+			if err != nil {
+				return err
+			}
+			pods, err := resolvePods(ir, args[0])
+			if err != nil {
+				return err
+			}
+			var containerLogs []reader.ContainerLog
+			for _, pod := range pods {
+				selected, err := selectLogs(ir.ListLogs(pod.GetName(), pod.GetNamespace(), "", previous), pod.GetName())
+				if err != nil {
+					if len(pods) == 1 {
+						return err
+					}
+					log.Warning(err)
+					continue
+				}
+				containerLogs = append(containerLogs, selected...)
+			}
+			if len(containerLogs) == 0 {
+				return fmt.Errorf("no logs found for %s", args[0])
+			}
+			for _, group := range groupByContainer(containerLogs) {
+				if err := writeLogs(os.Stdout, ir, group, filter); err != nil {
+					return err
+				}
+			}
+			return nil
 		},
 	}
 	containerName string
 	previous      bool
+	allContainers bool
+	prefix        bool
+	tail          int64
+	since         time.Duration
+	sinceTime     string
+	grep          string
 )
 
+// build the filter for the log lines from the flags, --since is relative to the time the archive was gathered
+func newLogFilter(gatherTime time.Time) (logs.Filter, error) {
+	filter := logs.Filter{Tail: tail, Reference: gatherTime}
+	if filter.Reference.IsZero() {
+		filter.Reference = time.Now()
+	}
+	switch {
+	case since != 0 && sinceTime != "":
+		return filter, fmt.Errorf("at most one of --since or --since-time may be specified")
+	case since != 0:
+		if gatherTime.IsZero() {
+			return filter, fmt.Errorf("unable to determine when the insights archive was gathered, use --since-time instead of --since")
+		}
+		filter.Since = gatherTime.Add(-since)
+		log.Infof("Reading log lines since %s, %s before the archive was gathered", filter.Since.Format(time.RFC3339), since)
+	case sinceTime != "":
+		t, err := time.Parse(time.RFC3339, sinceTime)
+		if err != nil {
+			return filter, fmt.Errorf("invalid --since-time '%s', expected an RFC3339 timestamp (e.g. 2024-10-16T12:00:00Z): %w", sinceTime, err)
+		}
+		filter.Since = t
+	}
+	if grep != "" {
+		re, err := regexp.Compile(grep)
+		if err != nil {
+			return filter, fmt.Errorf("invalid --grep expression '%s': %w", grep, err)
+		}
+		filter.Grep = re
+	}
+	return filter, nil
+}
+
+// resolve the pods to read logs from, given by name or as the pods of an owner, e.g. deployment/<name>
+func resolvePods(ir *reader.InsightsReader, arg string) ([]unstructured.Unstructured, error) {
+	resourceGroup, resourceName, ok := strings.Cut(arg, "/")
+	if !ok {
+		resourceGroup, resourceName = "pod", arg
+	}
+	resourceGroup = Unalias(resourceGroup)
+	if resourceGroup == "pod" || resourceGroup == "pods" {
+		pod := unstructured.Unstructured{Object: map[string]interface{}{}}
+		pod.SetName(resourceName)
+		pod.SetNamespace(Namespace)
+		return []unstructured.Unstructured{pod}, nil
+	}
+	pods, warnings, err := ir.ReadOwnedPods(resourceGroup, resourceName, Namespace)
+	if err != nil {
+		return nil, err
+	}
+	reportWarnings("", pods, warnings)
+	if len(pods.Items) == 0 {
+		return nil, fmt.Errorf("no pods found for %s", arg)
+	}
+	output.SortByName(pods)
+	var names []string
+	for _, pod := range pods.Items {
+		names = append(names, pod.GetNamespace()+"/"+pod.GetName())
+	}
+	log.Infof("Found %d pod(s) for %s: %s", len(names), arg, strings.Join(names, ", "))
+	if len(pods.Items) > 1 && !prefix {
+		log.Warningf("Found %d pods for %s, use --prefix to tell their logs apart", len(pods.Items), arg)
+	}
+	return pods.Items, nil
+}
+
+// select the logs of the requested container, all containers or the only container of the pod.
+// A container may have logs gathered in several sources, all of them are selected.
+func selectLogs(logs []reader.ContainerLog, podName string) ([]reader.ContainerLog, error) {
+	if len(logs) == 0 {
+		return nil, fmt.Errorf("no logs found for pod '%s'", podName)
+	}
+	var containers []string
+	for _, l := range logs {
+		if !slices.Contains(containers, l.Container) {
+			containers = append(containers, l.Container)
+		}
+	}
+	selected := containerName
+	switch {
+	case allContainers:
+		return logs, nil
+	case selected != "":
+		if !slices.Contains(containers, selected) {
+			return nil, fmt.Errorf("container '%s' is not valid for pod '%s', choose one of: %s", selected, podName, strings.Join(containers, ", "))
+		}
+	case len(containers) > 1:
+		return nil, fmt.Errorf("a container name must be specified for pod '%s', choose one of: %s, or use --all-containers", podName, strings.Join(containers, ", "))
+	default:
+		selected = containers[0]
+	}
+	var result []reader.ContainerLog
+	for _, l := range logs {
+		if l.Container == selected {
+			result = append(result, l)
+		}
+	}
+	if len(result) > 1 && !prefix {
+		log.Warningf("Container \"%s\" has logs from %d sources in the insights archive, use --prefix to tell them apart", selected, len(result))
+	}
+	return result, nil
+}
+
+// group logs by pod and container, keeping the order in which containers appear and the order of their sources
+func groupByContainer(containerLogs []reader.ContainerLog) [][]reader.ContainerLog {
+	var groups [][]reader.ContainerLog
+	index := make(map[string]int)
+	for _, l := range containerLogs {
+		key := l.Namespace + "/" + l.Pod + "/" + l.Container
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], l)
+	}
+	return groups
+}
+
+// copy the selected lines of a container's logs to out, prefixing each line with its source when requested.
+// The logs of all sources of the container are filtered as one, so --tail applies to their merged output.
+func writeLogs(out io.Writer, ir *reader.InsightsReader, containerLogs []reader.ContainerLog, filter logs.Filter) error {
+	var sources []logs.Source
+	for _, l := range containerLogs {
+		log.Infof("Reading logs of container \"%s\" from '%s'", l.Container, l.Path)
+		r, err := ir.ReadContainerLog(l)
+		if err != nil {
+			return err
+		}
+		sources = append(sources, logs.Source{Reader: r, Prefix: linePrefix(l)})
+	}
+	if !prefix && filter.IsZero() {
+		for _, source := range sources {
+			if _, err := io.Copy(out, source.Reader); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return filter.CopySources(out, sources...)
+}
+
+// the prefix marking the lines of a log with their source, empty unless requested
+func linePrefix(l reader.ContainerLog) string {
+	switch {
+	case !prefix:
+		return ""
+	case l.Source != reader.LogSourceConfig:
+		return fmt.Sprintf("[pod/%s/%s %s] ", l.Pod, l.Container, l.Source)
+	default:
+		return fmt.Sprintf("[pod/%s/%s] ", l.Pod, l.Container)
+	}
+}
+
 func init() {
 	InsightsCmd.AddCommand(logsCmd)
 
 	logsCmd.Flags().StringVarP(&containerName, "container", "c", "", "Container to read logs from.")
 	logsCmd.Flags().BoolVarP(&previous, "previous", "p", false, "Read from previous logs.")
+	logsCmd.Flags().BoolVar(&allContainers, "all-containers", false, "Read the logs of all containers in the pod.")
+	logsCmd.Flags().BoolVar(&prefix, "prefix", false, "Prefix each log line with the log source (pod name and container name).")
+	logsCmd.Flags().Int64Var(&tail, "tail", -1, "Lines of recent log file to display. Defaults to -1, showing all log lines.")
+	logsCmd.Flags().DurationVar(&since, "since", 0, "Only return logs newer than a relative duration like 5s, 2m, or 3h, counted back from the time the archive was gathered. Only one of since-time / since may be used.")
+	logsCmd.Flags().StringVar(&sinceTime, "since-time", "", "Only return logs after a specific date (RFC3339). Only one of since-time / since may be used.")
+	logsCmd.Flags().StringVar(&grep, "grep", "", "Only return log lines matching a regular expression (e.g. --grep 'E[0-9]{4}|panic').")
 }
@@ -0,0 +1,147 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/bverschueren/in2un/internal/testutil"
+	"github.com/bverschueren/in2un/pkg/logs"
+	"github.com/bverschueren/in2un/pkg/reader"
+)
+
+func TestWriteLogs(t *testing.T) {
+	dir := testutil.WriteArchive(t, map[string]string{
+		"config/pod/ns1/logs/web-abc-1/app_current.log":                                    "config 1\nconfig 2\n",
+		"config/pod/ns1/logs/web-abc-1/sidecar_current.log":                                "sidecar 1\n",
+		"conditional/namespaces/ns1/pods/web-abc-1/containers/app/logs/last-100-lines.log": "conditional 1\nconditional 2\n",
+	})
+	ir, err := reader.NewInsightsReader(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ir.Close()
+	tests := []struct {
+		name     string
+		tail     int64
+		prefix   bool
+		expected string
+	}{
+		{
+			name:     "all lines of both sources",
+			tail:     -1,
+			expected: "config 1\nconfig 2\nconditional 1\nconditional 2\n",
+		},
+		{
+			name:     "tail of the merged output",
+			tail:     1,
+			expected: "conditional 2\n",
+		},
+		{
+			name:     "tail spanning both sources",
+			tail:     3,
+			prefix:   true,
+			expected: "[pod/web-abc-1/app] config 2\n[pod/web-abc-1/app conditional] conditional 1\n[pod/web-abc-1/app conditional] conditional 2\n",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			prefix = tc.prefix
+			defer func() { prefix = false }()
+			groups := groupByContainer(ir.ListLogs("web-abc-1", "ns1", "", false))
+			if len(groups) != 2 || len(groups[0]) != 2 || groups[0][0].Container != "app" {
+				t.Fatalf("Expected: app logs from 2 sources and sidecar logs, got: %v", groups)
+			}
+			out := &bytes.Buffer{}
+			if err := writeLogs(out, ir, groups[0], logs.Filter{Tail: tc.tail}); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != tc.expected {
+				t.Fatalf("Expected: %q, got: %q", tc.expected, out.String())
+			}
+		})
+	}
+}
+
+func TestSelectLogs(t *testing.T) {
+	available := []reader.ContainerLog{
+		{Pod: "web-abc-1", Container: "app", Path: "config/pod/ns1/logs/web-abc-1/app_current.log"},
+		{Pod: "web-abc-1", Container: "sidecar", Path: "config/pod/ns1/logs/web-abc-1/sidecar_current.log"},
+		{Pod: "web-abc-1", Container: "app", Path: "conditional/namespaces/ns1/pods/web-abc-1/containers/app/logs/last-100-lines.log"},
+	}
+	tests := []struct {
+		name          string
+		logs          []reader.ContainerLog
+		container     string
+		all           bool
+		expectedPaths []string
+		expectedErr   string
+	}{
+		{
+			name:          "single container",
+			logs:          available[1:2],
+			expectedPaths: []string{available[1].Path},
+		},
+		{
+			name:          "selected container from all sources",
+			logs:          available,
+			container:     "app",
+			expectedPaths: []string{available[0].Path, available[2].Path},
+		},
+		{
+			name:          "all containers",
+			logs:          available,
+			all:           true,
+			expectedPaths: []string{available[0].Path, available[1].Path, available[2].Path},
+		},
+		{
+			name:        "several containers without selection",
+			logs:        available,
+			expectedErr: "choose one of: app, sidecar",
+		},
+		{
+			name:        "unknown container",
+			logs:        available,
+			container:   "db",
+			expectedErr: "choose one of: app, sidecar",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			containerName, allContainers = tc.container, tc.all
+			defer func() { containerName, allContainers = "", false }()
+			got, err := selectLogs(tc.logs, "web-abc-1")
+			if tc.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+					t.Fatalf("Expected: error containing '%s', got: %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var paths []string
+			for _, l := range got {
+				paths = append(paths, l.Path)
+			}
+			if strings.Join(paths, ",") != strings.Join(tc.expectedPaths, ",") {
+				t.Fatalf("Expected: %v, got: %v", tc.expectedPaths, paths)
+			}
+		})
+	}
+}
@@ -16,8 +16,11 @@ limitations under the License.
 package cmd
 
 import (
+	"errors"
 	"os"
 
+	"github.com/bverschueren/in2un/pkg/reader"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -27,6 +30,9 @@ var (
 		Use:   "in2un",
 		Args:  cobra.MinimumNArgs(1),
 		Short: "Parse Insights data as unstructed data or raw log lines.",
+		// errors are logged and mapped to an exit code in Execute
+		SilenceErrors: true,
+		SilenceUsage:  true,
 		PersistentPreRun: func(cmd *cobra.Command, args []string) {
 			initConfig()
 		},
@@ -39,10 +45,31 @@ var (
 	configFileType                                           = "json"
 )
 
+const (
+	exitError = 1
+	// the provided file is not a (supported) insights archive
+	exitInvalidArchive = 2
+	// the insights archive is corrupt, truncated or holds unparseable data
+	exitCorruptArchive = 3
+)
+
 func Execute() {
 	err := InsightsCmd.Execute()
 	if err != nil {
-		os.Exit(1)
+		log.Error(err)
+		os.Exit(exitCode(err))
+	}
+}
+
+func exitCode(err error) int {
+	var parseErr *reader.ParseError
+	switch {
+	case errors.Is(err, reader.ErrInvalidInsightsArchive):
+		return exitInvalidArchive
+	case errors.Is(err, reader.ErrCorruptArchive), errors.Is(err, reader.ErrTruncatedEntry), errors.As(err, &parseErr):
+		return exitCorruptArchive
+	default:
+		return exitError
 	}
 }
 
@@ -0,0 +1,89 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/bverschueren/in2un/pkg/reader"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       error
+		expected int
+	}{
+		{
+			name:     "generic error",
+			in:       fmt.Errorf("something went wrong"),
+			expected: exitError,
+		},
+		{
+			name:     "invalid archive",
+			in:       &reader.UnsupportedFormatError{Path: "file", Detected: "unrecognized content"},
+			expected: exitInvalidArchive,
+		},
+		{
+			name:     "truncated entry",
+			in:       &reader.EntryError{Path: "config/ingress.json", Err: fmt.Errorf("%w: unexpected EOF", reader.ErrTruncatedEntry)},
+			expected: exitCorruptArchive,
+		},
+		{
+			name:     "unparseable entry",
+			in:       fmt.Errorf("reading ingress: %w", &reader.ParseError{Path: "config/ingress.json", Err: fmt.Errorf("unexpected end of JSON input")}),
+			expected: exitCorruptArchive,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := exitCode(tc.in); got != tc.expected {
+				t.Fatalf("Expected: %d, got: %d", tc.expected, got)
+			}
+		})
+	}
+}
+
+// commands sharing a flag variable overwrite each other's default, api-resources must not see the table default of get
+func TestAPIResourcesOutputDefault(t *testing.T) {
+	if apiResourcesOutput != "" {
+		t.Fatalf("Expected: empty output format for api-resources, got: %s", apiResourcesOutput)
+	}
+	if apiResourcesNoHeaders || NoHeaders {
+		t.Fatalf("Expected: headers for api-resources and get, got: %t, %t", apiResourcesNoHeaders, NoHeaders)
+	}
+	if Output != "table" {
+		t.Fatalf("Expected: table output format for get, got: %s", Output)
+	}
+}
+
+func TestEventsOutputFlags(t *testing.T) {
+	if err := eventsCmd.Flags().Parse([]string{"-o", "name", "--no-headers"}); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		eventsCmd.Flags().Set("output", "table")
+		eventsCmd.Flags().Set("no-headers", "false")
+	}()
+	if eventsOutput != "name" || !eventsNoHeaders {
+		t.Fatalf("Expected: name output without headers for events, got: %s, %t", eventsOutput, eventsNoHeaders)
+	}
+	if Output != "table" || NoHeaders {
+		t.Fatalf("Expected: get flags unchanged, got: %s, %t", Output, NoHeaders)
+	}
+}
@@ -0,0 +1,104 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/bverschueren/in2un/pkg/reader"
+	"github.com/bverschueren/in2un/pkg/server"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+var (
+	serveAddress    string
+	serveKubeconfig string
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Args:  cobra.NoArgs,
+	Short: "Serve the active insights archive as a read-only Kubernetes API for kubectl, oc and client-go based tools.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ir, err := reader.NewInsightsReader(viper.GetString("active"), reader.WithIndexCache(ConfigDir))
+		if err != nil {
+			return err
+		}
+		defer ir.Close()
+		handler, err := server.NewServer(ir)
+		if err != nil {
+			return err
+		}
+		listener, err := net.Listen("tcp", serveAddress)
+		if err != nil {
+			return err
+		}
+		kubeconfig := serveKubeconfig
+		if kubeconfig == "" {
+			kubeconfig = filepath.Join(ConfigDir, "kubeconfig")
+		}
+		if err := writeKubeconfig(kubeconfig, "http://"+listener.Addr().String()); err != nil {
+			listener.Close()
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Serving %s on http://%s\nTo browse it run: export KUBECONFIG=%s\n", ir.Path, listener.Addr(), kubeconfig)
+
+		srv := &http.Server{Handler: handler}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		go func() {
+			<-ctx.Done()
+			log.Debug("Shutting down")
+			srv.Shutdown(context.Background())
+		}()
+		if err := srv.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	},
+}
+
+// write a kubeconfig with a single context pointing to the local server
+func writeKubeconfig(path, server string) error {
+	config := clientcmdapi.NewConfig()
+	config.Clusters["in2un"] = &clientcmdapi.Cluster{Server: server}
+	config.AuthInfos["in2un"] = &clientcmdapi.AuthInfo{}
+	config.Contexts["in2un"] = &clientcmdapi.Context{Cluster: "in2un", AuthInfo: "in2un", Namespace: "default"}
+	config.CurrentContext = "in2un"
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return clientcmd.WriteToFile(*config, path)
+}
+
+func init() {
+	InsightsCmd.AddCommand(serveCmd)
+	serveCmd.Flags().StringVar(&serveAddress, "address", "127.0.0.1:8001", "The address to serve on, use port 0 to pick a free port.")
+	serveCmd.Flags().StringVar(&serveKubeconfig, "kubeconfig", "", "Path to write a kubeconfig for the server to. Defaults to kubeconfig in the in2un config directory.")
+}
@@ -0,0 +1,66 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/bverschueren/in2un/pkg/reader"
+	"github.com/bverschueren/in2un/pkg/summary"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// summary has its own output flag, the formats of get do not apply
+var summaryOutput string
+
+var summaryCmd = &cobra.Command{
+	Use:   "summary",
+	Args:  cobra.MaximumNArgs(0),
+	Short: "Show an overview of the cluster an insights archive was gathered from.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if summaryOutput != "" && summaryOutput != "json" {
+			return fmt.Errorf("unable to match a printer suitable for the output format %q, allowed formats are: json", summaryOutput)
+		}
+		ir, err := reader.NewInsightsReader(viper.GetString("active"), reader.WithIndexCache(ConfigDir))
+		if err != nil {
+			return err
+		}
+		defer ir.Close()
+		s, warnings, err := summary.Summarize(ir)
+		if err != nil {
+			return err
+		}
+		reportWarnings(summaryOutput, nil, warnings)
+		if summaryOutput == "json" {
+			raw, err := json.MarshalIndent(s, "", "    ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(raw))
+			return nil
+		}
+		return summary.Print(os.Stdout, s, time.Now())
+	},
+}
+
+func init() {
+	InsightsCmd.AddCommand(summaryCmd)
+	summaryCmd.Flags().StringVarP(&summaryOutput, "output", "o", "", "Output format. One of: (json).")
+}
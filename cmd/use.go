@@ -18,8 +18,6 @@ package cmd
 import (
 	"os"
 
-	log "github.com/sirupsen/logrus"
-
 	"path/filepath"
 
 	"github.com/bverschueren/in2un/pkg/reader"
@@ -32,18 +30,20 @@ var useCmd = &cobra.Command{
 	Args:             cobra.MinimumNArgs(1),
 	Short:            "Specify the insights file to read from",
 	PersistentPreRun: nil,
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		insightsArchive, _ := filepath.Abs(args[0])
-		active, err := reader.NewInsightsReader(insightsArchive)
+		// build the index once so subsequent commands can reuse it
+		active, err := reader.NewInsightsReader(insightsArchive, reader.WithIndexCache(ConfigDir))
 		if err != nil {
-			log.Fatal(err)
+			return err
 		}
+		defer active.Close()
 		err = os.MkdirAll(ConfigDir, 0750)
 		if err != nil {
-			log.Fatal(err)
+			return err
 		}
 		viper.Set("active", active.Path)
-		viper.WriteConfigAs(filepath.Join(ConfigDir, configFileName) + "." + configFileType)
+		return viper.WriteConfigAs(filepath.Join(ConfigDir, configFileName) + "." + configFileType)
 	},
 }
 
@@ -0,0 +1,59 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Package testutil holds helpers shared by the tests of in2un
+package testutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// GatherTime is the modification time of the files written by WriteArchive, so the archive's gather time
+var GatherTime = time.Date(2024, 10, 16, 12, 0, 0, 0, time.UTC)
+
+// WriteArchive lays out files, keyed by their path in the archive, as an extracted insights archive
+// in a temporary directory and returns the directory
+func WriteArchive(t testing.TB, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, body := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, GatherTime, GatherTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// Unstructured parses raw JSON into an unstructured object
+func Unstructured(t testing.TB, raw string) unstructured.Unstructured {
+	t.Helper()
+	obj := unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON([]byte(raw)); err != nil {
+		t.Fatal(err)
+	}
+	return obj
+}
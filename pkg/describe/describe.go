@@ -0,0 +1,308 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package describe
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode"
+
+	"github.com/bverschueren/in2un/pkg/helpers"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// describer writes the kind-specific description of an object
+type describer func(w *prefixWriter, obj *unstructured.Unstructured)
+
+var describers = map[schema.GroupKind]describer{}
+
+// Describe writes a human readable description of obj and its related events, similar to kubectl describe.
+// Kinds without a specific describer get all their fields listed.
+func Describe(out io.Writer, obj *unstructured.Unstructured, events []unstructured.Unstructured, now time.Time) error {
+	buf := &bytes.Buffer{}
+	w := &prefixWriter{out: buf, now: now}
+	describe, ok := describers[obj.GroupVersionKind().GroupKind()]
+	if !ok {
+		describe = describeGeneric
+	}
+	describe(w, obj)
+	describeEvents(w, RelatedEvents(obj, events))
+
+	tw := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	if _, err := tw.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	return tw.Flush()
+}
+
+// RelatedEvents returns the events involving obj ordered by time. Compacted events in insights archives lack
+// the involved object, those are related on a best effort basis when their message mentions the object.
+func RelatedEvents(obj *unstructured.Unstructured, events []unstructured.Unstructured) []unstructured.Unstructured {
+	var result []unstructured.Unstructured
+	for _, event := range events {
+		if obj.GetNamespace() != "" && event.GetNamespace() != obj.GetNamespace() {
+			continue
+		}
+		involved, found, _ := unstructured.NestedMap(event.Object, "involvedObject")
+		if found {
+			if helpers.StringAt(involved, "name") == obj.GetName() && (helpers.StringAt(involved, "kind") == "" || strings.EqualFold(helpers.StringAt(involved, "kind"), obj.GetKind())) {
+				result = append(result, event)
+			}
+			continue
+		}
+		if mentions(helpers.StringAt(event.Object, "message"), obj.GetName()) {
+			result = append(result, event)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return helpers.EventTime(&result[i]).Before(helpers.EventTime(&result[j]))
+	})
+	return result
+}
+
+// whether message mentions name as a whole word, e.g. 'pod a_ns1(uid)' mentions 'a' but 'image' does not
+func mentions(message, name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := strings.Index(message, name); i >= 0; {
+		end := i + len(name)
+		if (i == 0 || !isNameChar(message[i-1])) && (end == len(message) || !isNameChar(message[end])) {
+			return true
+		}
+		next := strings.Index(message[i+1:], name)
+		if next < 0 {
+			break
+		}
+		i += next + 1
+	}
+	return false
+}
+
+func isNameChar(c byte) bool {
+	return c == '-' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+// prefixWriter writes lines indented by level, columns are separated by tabs and aligned afterwards
+type prefixWriter struct {
+	out io.Writer
+	// descriptions of timestamps are relative to now
+	now time.Time
+}
+
+func (w *prefixWriter) Write(level int, format string, a ...interface{}) {
+	fmt.Fprintf(w.out, strings.Repeat("  ", level)+format, a...)
+}
+
+// write a map as key=value lines, e.g. labels
+func (w *prefixWriter) writeMap(level int, title string, m map[string]string, separator string) {
+	w.Write(level, "%s:\t", title)
+	if len(m) == 0 {
+		w.Write(0, "<none>\n")
+		return
+	}
+	for i, key := range slices.Sorted(maps.Keys(m)) {
+		if i > 0 {
+			w.Write(level, "\t")
+		}
+		w.Write(0, "%s%s%s\n", key, separator, m[key])
+	}
+}
+
+// write a list of values as lines, e.g. finalizers
+func (w *prefixWriter) writeList(level int, title string, values []string) {
+	w.Write(level, "%s:\t", title)
+	if len(values) == 0 {
+		w.Write(0, "<none>\n")
+		return
+	}
+	for i, value := range values {
+		if i > 0 {
+			w.Write(level, "\t")
+		}
+		w.Write(0, "%s\n", value)
+	}
+}
+
+// write the status conditions of an object as a table with the given fields as columns
+func (w *prefixWriter) writeConditions(obj *unstructured.Unstructured, fields ...string) {
+	conditions := helpers.MapsAt(obj.Object, "status", "conditions")
+	if len(conditions) == 0 {
+		w.Write(0, "Conditions:\t<none>\n")
+		return
+	}
+	w.Write(0, "Conditions:\n")
+	headers := make([]string, len(fields))
+	separators := make([]string, len(fields))
+	for i, field := range fields {
+		headers[i] = strings.ToUpper(field[:1]) + field[1:]
+		separators[i] = strings.Repeat("-", len(headers[i]))
+	}
+	w.Write(1, "%s\n", strings.Join(headers, "\t"))
+	w.Write(1, "%s\n", strings.Join(separators, "\t"))
+	for _, condition := range conditions {
+		values := make([]string, len(fields))
+		for i, field := range fields {
+			values[i] = fmt.Sprint(valueOr(condition[field], ""))
+			if strings.HasSuffix(field, "Time") && values[i] != "" {
+				values[i] = formatTime(values[i])
+			}
+		}
+		w.Write(1, "%s\n", strings.Join(values, "\t"))
+	}
+}
+
+// write the common metadata of an object
+func (w *prefixWriter) writeMetadata(obj *unstructured.Unstructured) {
+	w.Write(0, "Name:\t%s\n", obj.GetName())
+	if obj.GetNamespace() != "" {
+		w.Write(0, "Namespace:\t%s\n", obj.GetNamespace())
+	}
+	w.writeMap(0, "Labels", obj.GetLabels(), "=")
+	w.writeMap(0, "Annotations", obj.GetAnnotations(), ": ")
+}
+
+func (w *prefixWriter) writeCreationTimestamp(obj *unstructured.Unstructured) {
+	w.Write(0, "CreationTimestamp:\t%s\n", timestamp(obj.GetCreationTimestamp()))
+}
+
+func describeEvents(w *prefixWriter, events []unstructured.Unstructured) {
+	if len(events) == 0 {
+		w.Write(0, "Events:\t<none>\n")
+		return
+	}
+	w.Write(0, "Events:\n")
+	w.Write(1, "Type\tReason\tAge\tFrom\tMessage\n")
+	w.Write(1, "----\t------\t----\t----\t-------\n")
+	for _, event := range events {
+		w.Write(1, "%s\t%s\t%s\t%s\t%s\n",
+			helpers.StringAt(event.Object, "type"),
+			helpers.StringAt(event.Object, "reason"),
+			helpers.EventAge(&event, w.now),
+			eventSource(event),
+			strings.TrimSpace(helpers.StringAt(event.Object, "message")),
+		)
+	}
+}
+
+func eventSource(event unstructured.Unstructured) string {
+	source := helpers.StringAt(event.Object, "source", "component")
+	if source == "" {
+		source = helpers.StringAt(event.Object, "reportingComponent")
+	}
+	if host := helpers.StringAt(event.Object, "source", "host"); host != "" {
+		source += ", " + host
+	}
+	return source
+}
+
+// list all fields of an object as nested key/value pairs
+func describeGeneric(w *prefixWriter, obj *unstructured.Unstructured) {
+	w.writeMetadata(obj)
+	w.Write(0, "API Version:\t%s\n", obj.GetAPIVersion())
+	w.Write(0, "Kind:\t%s\n", obj.GetKind())
+	metadata, _, _ := unstructured.NestedMap(obj.Object, "metadata")
+	delete(metadata, "name")
+	delete(metadata, "namespace")
+	delete(metadata, "labels")
+	delete(metadata, "annotations")
+	content := map[string]interface{}{"metadata": metadata}
+	for key, value := range obj.Object {
+		if key != "apiVersion" && key != "kind" && key != "metadata" {
+			content[key] = value
+		}
+	}
+	writeContent(w, 0, content)
+}
+
+func writeContent(w *prefixWriter, level int, content map[string]interface{}) {
+	for _, key := range slices.Sorted(maps.Keys(content)) {
+		switch value := content[key].(type) {
+		case map[string]interface{}:
+			if len(value) == 0 {
+				continue
+			}
+			w.Write(level, "%s:\n", labelFor(key))
+			writeContent(w, level+1, value)
+		case []interface{}:
+			if len(value) == 0 {
+				continue
+			}
+			w.Write(level, "%s:\n", labelFor(key))
+			for _, item := range value {
+				if m, ok := item.(map[string]interface{}); ok {
+					writeContent(w, level+1, m)
+				} else {
+					w.Write(level+1, "%v\n", item)
+				}
+			}
+		default:
+			w.Write(level, "%s:\t%v\n", labelFor(key), value)
+		}
+	}
+}
+
+// turn a field name into a label, e.g. lastTransitionTime into Last Transition Time
+func labelFor(field string) string {
+	var label []rune
+	for i, r := range field {
+		if i == 0 {
+			r = unicode.ToUpper(r)
+		} else if unicode.IsUpper(r) && !unicode.IsUpper(rune(field[i-1])) {
+			label = append(label, ' ')
+		}
+		label = append(label, r)
+	}
+	return string(label)
+}
+
+func timestamp(t metav1.Time) string {
+	if t.IsZero() {
+		return "<unknown>"
+	}
+	return t.Format(time.RFC1123Z)
+}
+
+// format an RFC3339 timestamp from unstructured content like kubectl does
+func formatTime(s string) string {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return helpers.ValueOrNone(s)
+	}
+	return timestamp(metav1.NewTime(t))
+}
+
+// the string map at fields, e.g. a node selector
+func stringMap(obj map[string]interface{}, fields ...string) map[string]string {
+	m, _, _ := unstructured.NestedStringMap(obj, fields...)
+	return m
+}
+
+func valueOr(value interface{}, fallback interface{}) interface{} {
+	if value == nil {
+		return fallback
+	}
+	return value
+}
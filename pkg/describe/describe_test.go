@@ -0,0 +1,153 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package describe
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bverschueren/in2un/internal/testutil"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestRelatedEvents(t *testing.T) {
+	events := []unstructured.Unstructured{
+		testutil.Unstructured(t, `{"apiVersion":"v1","kind":"Event","metadata":{"name":"e0","namespace":"ns1"},"lastTimestamp":"2024-10-16T12:00:00Z","message":"Back-off restarting failed container c in pod etcd_ns1(123)"}`),
+		testutil.Unstructured(t, `{"apiVersion":"v1","kind":"Event","metadata":{"name":"e1","namespace":"ns1"},"lastTimestamp":"2024-10-16T11:00:00Z","message":"Started container etcd"}`),
+		testutil.Unstructured(t, `{"apiVersion":"v1","kind":"Event","metadata":{"name":"e2","namespace":"ns1"},"lastTimestamp":"2024-10-16T10:00:00Z","message":"Pulled image for etcd-guard"}`),
+		testutil.Unstructured(t, `{"apiVersion":"v1","kind":"Event","metadata":{"name":"e3","namespace":"ns2"},"lastTimestamp":"2024-10-16T10:00:00Z","message":"Scheduled etcd"}`),
+		testutil.Unstructured(t, `{"apiVersion":"v1","kind":"Event","metadata":{"name":"e4","namespace":"ns1"},"lastTimestamp":"2024-10-16T09:00:00Z","message":"Killing","involvedObject":{"kind":"Pod","name":"etcd"}}`),
+		testutil.Unstructured(t, `{"apiVersion":"v1","kind":"Event","metadata":{"name":"e5","namespace":"ns1"},"lastTimestamp":"2024-10-16T09:00:00Z","message":"Scaled etcd","involvedObject":{"kind":"Deployment","name":"etcd"}}`),
+	}
+	tests := []struct {
+		name     string
+		raw      string
+		expected []string
+	}{
+		{
+			name:     "namespaced object by involved object and by message",
+			raw:      `{"apiVersion":"v1","kind":"Pod","metadata":{"name":"etcd","namespace":"ns1"}}`,
+			expected: []string{"e4", "e1", "e0"},
+		},
+		{
+			name:     "cluster scoped object in any namespace",
+			raw:      `{"apiVersion":"v1","kind":"Node","metadata":{"name":"etcd"}}`,
+			expected: []string{"e3", "e1", "e0"},
+		},
+		{
+			name:     "no related events",
+			raw:      `{"apiVersion":"v1","kind":"Pod","metadata":{"name":"e","namespace":"ns1"}}`,
+			expected: nil,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			obj := testutil.Unstructured(t, tc.raw)
+			var got []string
+			for _, event := range RelatedEvents(&obj, events) {
+				got = append(got, event.GetName())
+			}
+			if !reflect.DeepEqual(tc.expected, got) {
+				t.Fatalf("Expected: %v, got: %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	now := time.Date(2024, 10, 16, 12, 0, 0, 0, time.UTC)
+	events := []unstructured.Unstructured{
+		testutil.Unstructured(t, `{"apiVersion":"v1","kind":"Event","metadata":{"name":"e0","namespace":"ns1"},"type":"Warning","reason":"BackOff","count":3,"firstTimestamp":"2024-10-16T11:00:00Z","lastTimestamp":"2024-10-16T11:55:00Z","source":{"component":"kubelet"},"message":"Back-off restarting failed container c in pod pod_ns1(123)"}`),
+	}
+	tests := []struct {
+		name     string
+		raw      string
+		expected []string
+	}{
+		{
+			name: "pod",
+			raw:  `{"apiVersion":"v1","kind":"Pod","metadata":{"name":"pod","namespace":"ns1","labels":{"app":"a","tier":"b"},"ownerReferences":[{"kind":"ReplicaSet","name":"rs","controller":true}]},"spec":{"nodeName":"master-0","containers":[{"name":"c","image":"quay.io/c"}]},"status":{"phase":"Running","podIP":"10.0.0.1","conditions":[{"type":"Ready","status":"False"}],"containerStatuses":[{"name":"c","ready":false,"restartCount":3,"state":{"waiting":{"reason":"CrashLoopBackOff"}},"lastState":{"terminated":{"exitCode":1,"reason":"Error"}}}]}}`,
+			expected: []string{
+				"Name:             pod",
+				"Namespace:        ns1",
+				"Node:             master-0",
+				"Labels:           app=a",
+				"                  tier=b",
+				"Status:           Running",
+				"Controlled By:    ReplicaSet/rs",
+				"  c:",
+				"    Image:          quay.io/c",
+				"    State:          Waiting",
+				"      Reason:       CrashLoopBackOff",
+				"    Last State:     Terminated",
+				"      Exit Code:    1",
+				"    Ready:          False",
+				"    Restart Count:  3",
+				"  Ready          False",
+				"  Warning  BackOff  5m (x3 over 60m)  kubelet  Back-off restarting failed container c in pod pod_ns1(123)",
+			},
+		},
+		{
+			name: "cluster operator",
+			raw:  `{"apiVersion":"config.openshift.io/v1","kind":"ClusterOperator","metadata":{"name":"etcd"},"status":{"versions":[{"name":"operator","version":"4.16.0"}],"conditions":[{"type":"Degraded","status":"True","reason":"Unhealthy","lastTransitionTime":"2024-10-16T11:00:00Z"}],"relatedObjects":[{"group":"","resource":"namespaces","name":"openshift-etcd"}]}}`,
+			expected: []string{
+				"Versions:",
+				"  operator:  4.16.0",
+				"  Degraded  True    Wed, 16 Oct 2024 11:00:00 +0000  Unhealthy",
+				"         namespaces             openshift-etcd",
+				"Events:  <none>",
+			},
+		},
+		{
+			name: "unknown kind",
+			raw:  `{"apiVersion":"example.com/v1","kind":"Fake","metadata":{"name":"fake"},"spec":{"replicaCount":2,"hosts":["a","b"]}}`,
+			expected: []string{
+				"Kind:         Fake",
+				"Spec:",
+				"  Hosts:",
+				"    a",
+				"  Replica Count:  2",
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			obj := testutil.Unstructured(t, tc.raw)
+			out := &bytes.Buffer{}
+			if err := Describe(out, &obj, events, now); err != nil {
+				t.Fatal(err)
+			}
+			lines := strings.Split(out.String(), "\n")
+			for _, expected := range tc.expected {
+				if !containsLine(lines, expected) {
+					t.Fatalf("Expected: line %q, got: \n%s", expected, out.String())
+				}
+			}
+		})
+	}
+}
+
+func containsLine(lines []string, prefix string) bool {
+	for _, line := range lines {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,321 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package describe
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/bverschueren/in2un/pkg/helpers"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func init() {
+	describers[schema.GroupKind{Kind: "Pod"}] = describePod
+	describers[schema.GroupKind{Kind: "Node"}] = describeNode
+	describers[schema.GroupKind{Kind: "PersistentVolumeClaim"}] = describePersistentVolumeClaim
+	describers[schema.GroupKind{Group: "config.openshift.io", Kind: "ClusterOperator"}] = describeClusterOperator
+	describers[schema.GroupKind{Group: "machineconfiguration.openshift.io", Kind: "MachineConfigPool"}] = describeMachineConfigPool
+}
+
+func describePod(w *prefixWriter, obj *unstructured.Unstructured) {
+	w.Write(0, "Name:\t%s\n", obj.GetName())
+	w.Write(0, "Namespace:\t%s\n", obj.GetNamespace())
+	if priority, found, _ := unstructured.NestedInt64(obj.Object, "spec", "priority"); found {
+		w.Write(0, "Priority:\t%d\n", priority)
+	}
+	if priorityClass := helpers.StringAt(obj.Object, "spec", "priorityClassName"); priorityClass != "" {
+		w.Write(0, "Priority Class Name:\t%s\n", priorityClass)
+	}
+	w.Write(0, "Service Account:\t%s\n", helpers.ValueOrNone(helpers.StringAt(obj.Object, "spec", "serviceAccountName")))
+	node := helpers.ValueOrNone(helpers.StringAt(obj.Object, "spec", "nodeName"))
+	if hostIP := helpers.StringAt(obj.Object, "status", "hostIP"); hostIP != "" {
+		node += "/" + hostIP
+	}
+	w.Write(0, "Node:\t%s\n", node)
+	if startTime := helpers.StringAt(obj.Object, "status", "startTime"); startTime != "" {
+		w.Write(0, "Start Time:\t%s\n", formatTime(startTime))
+	}
+	w.writeMap(0, "Labels", obj.GetLabels(), "=")
+	w.writeMap(0, "Annotations", obj.GetAnnotations(), ": ")
+	status := helpers.StringAt(obj.Object, "status", "phase")
+	if obj.GetDeletionTimestamp() != nil {
+		status = "Terminating (lasts " + formatTime(helpers.StringAt(obj.Object, "metadata", "deletionTimestamp")) + ")"
+	}
+	w.Write(0, "Status:\t%s\n", helpers.ValueOrNone(status))
+	if reason := helpers.StringAt(obj.Object, "status", "reason"); reason != "" {
+		w.Write(0, "Reason:\t%s\n", reason)
+	}
+	if message := helpers.StringAt(obj.Object, "status", "message"); message != "" {
+		w.Write(0, "Message:\t%s\n", message)
+	}
+	w.Write(0, "IP:\t%s\n", helpers.StringAt(obj.Object, "status", "podIP"))
+	var ips []string
+	for _, ip := range helpers.MapsAt(obj.Object, "status", "podIPs") {
+		ips = append(ips, helpers.StringAt(ip, "ip"))
+	}
+	w.writeList(0, "IPs", ips)
+	for _, owner := range obj.GetOwnerReferences() {
+		if owner.Controller != nil && *owner.Controller {
+			w.Write(0, "Controlled By:\t%s/%s\n", owner.Kind, owner.Name)
+		}
+	}
+	if len(helpers.MapsAt(obj.Object, "spec", "initContainers")) > 0 {
+		w.Write(0, "Init Containers:\n")
+		describeContainers(w, obj, "initContainers", "initContainerStatuses")
+	}
+	w.Write(0, "Containers:\n")
+	describeContainers(w, obj, "containers", "containerStatuses")
+	w.writeConditions(obj, "type", "status")
+	w.Write(0, "QoS Class:\t%s\n", helpers.ValueOrNone(helpers.StringAt(obj.Object, "status", "qosClass")))
+	w.writeMap(0, "Node-Selectors", stringMap(obj.Object, "spec", "nodeSelector"), "=")
+	var tolerations []string
+	for _, toleration := range helpers.MapsAt(obj.Object, "spec", "tolerations") {
+		tolerations = append(tolerations, formatToleration(toleration))
+	}
+	w.writeList(0, "Tolerations", tolerations)
+}
+
+// describe the containers in the spec field alongside their state from the status field
+func describeContainers(w *prefixWriter, obj *unstructured.Unstructured, specField, statusField string) {
+	statuses := map[string]map[string]interface{}{}
+	for _, status := range helpers.MapsAt(obj.Object, "status", statusField) {
+		statuses[helpers.StringAt(status, "name")] = status
+	}
+	for _, container := range helpers.MapsAt(obj.Object, "spec", specField) {
+		w.Write(1, "%s:\n", helpers.StringAt(container, "name"))
+		status, hasStatus := statuses[helpers.StringAt(container, "name")]
+		if hasStatus {
+			w.Write(2, "Container ID:\t%s\n", helpers.StringAt(status, "containerID"))
+		}
+		w.Write(2, "Image:\t%s\n", helpers.StringAt(container, "image"))
+		if hasStatus {
+			w.Write(2, "Image ID:\t%s\n", helpers.StringAt(status, "imageID"))
+		}
+		var ports []string
+		for _, port := range helpers.MapsAt(container, "ports") {
+			containerPort, _, _ := unstructured.NestedInt64(port, "containerPort")
+			protocol := helpers.StringAt(port, "protocol")
+			if protocol == "" {
+				protocol = "TCP"
+			}
+			ports = append(ports, fmt.Sprintf("%d/%s", containerPort, protocol))
+		}
+		w.Write(2, "Port:\t%s\n", helpers.ValueOrNone(strings.Join(ports, ", ")))
+		if command, _, _ := unstructured.NestedStringSlice(container, "command"); len(command) > 0 {
+			w.writeList(2, "Command", command)
+		}
+		if args, _, _ := unstructured.NestedStringSlice(container, "args"); len(args) > 0 {
+			w.writeList(2, "Args", args)
+		}
+		if hasStatus {
+			describeContainerState(w, "State", status, "state")
+			if last, _, _ := unstructured.NestedMap(status, "lastState"); len(last) > 0 {
+				describeContainerState(w, "Last State", status, "lastState")
+			}
+			ready := "False"
+			if r, _, _ := unstructured.NestedBool(status, "ready"); r {
+				ready = "True"
+			}
+			w.Write(2, "Ready:\t%s\n", ready)
+			restarts, _, _ := unstructured.NestedInt64(status, "restartCount")
+			w.Write(2, "Restart Count:\t%d\n", restarts)
+		}
+		if limits := stringMap(container, "resources", "limits"); len(limits) > 0 {
+			w.writeMap(2, "Limits", limits, ": ")
+		}
+		if requests := stringMap(container, "resources", "requests"); len(requests) > 0 {
+			w.writeMap(2, "Requests", requests, ": ")
+		}
+		var mounts []string
+		for _, mount := range helpers.MapsAt(container, "volumeMounts") {
+			mode := "rw"
+			if readOnly, _, _ := unstructured.NestedBool(mount, "readOnly"); readOnly {
+				mode = "ro"
+			}
+			mounts = append(mounts, fmt.Sprintf("%s from %s (%s)", helpers.StringAt(mount, "mountPath"), helpers.StringAt(mount, "name"), mode))
+		}
+		w.writeList(2, "Mounts", mounts)
+	}
+}
+
+// describe a container state, e.g. the reason and exit code of a terminated container
+func describeContainerState(w *prefixWriter, title string, status map[string]interface{}, field string) {
+	state, _, _ := unstructured.NestedMap(status, field)
+	switch {
+	case state["running"] != nil:
+		w.Write(2, "%s:\tRunning\n", title)
+		w.Write(3, "Started:\t%s\n", formatTime(helpers.StringAt(state, "running", "startedAt")))
+	case state["waiting"] != nil:
+		w.Write(2, "%s:\tWaiting\n", title)
+		w.Write(3, "Reason:\t%s\n", helpers.StringAt(state, "waiting", "reason"))
+	case state["terminated"] != nil:
+		w.Write(2, "%s:\tTerminated\n", title)
+		w.Write(3, "Reason:\t%s\n", helpers.StringAt(state, "terminated", "reason"))
+		if message := helpers.StringAt(state, "terminated", "message"); message != "" {
+			w.Write(3, "Message:\t%s\n", message)
+		}
+		exitCode, _, _ := unstructured.NestedInt64(state, "terminated", "exitCode")
+		w.Write(3, "Exit Code:\t%d\n", exitCode)
+		w.Write(3, "Started:\t%s\n", formatTime(helpers.StringAt(state, "terminated", "startedAt")))
+		w.Write(3, "Finished:\t%s\n", formatTime(helpers.StringAt(state, "terminated", "finishedAt")))
+	default:
+		w.Write(2, "%s:\tWaiting\n", title)
+	}
+}
+
+func formatToleration(toleration map[string]interface{}) string {
+	s := helpers.StringAt(toleration, "key")
+	if value := helpers.StringAt(toleration, "value"); value != "" {
+		s += "=" + value
+	} else if helpers.StringAt(toleration, "operator") == "Exists" && s != "" {
+		s += " op=Exists"
+	}
+	if effect := helpers.StringAt(toleration, "effect"); effect != "" {
+		s += ":" + effect
+	}
+	if seconds, found, _ := unstructured.NestedInt64(toleration, "tolerationSeconds"); found {
+		s += fmt.Sprintf(" for %ds", seconds)
+	}
+	if s == "" {
+		s = "op=Exists"
+	}
+	return s
+}
+
+func describeNode(w *prefixWriter, obj *unstructured.Unstructured) {
+	w.Write(0, "Name:\t%s\n", obj.GetName())
+	var roles []string
+	for label := range obj.GetLabels() {
+		if role, ok := strings.CutPrefix(label, "node-role.kubernetes.io/"); ok && role != "" {
+			roles = append(roles, role)
+		}
+	}
+	slices.Sort(roles)
+	w.Write(0, "Roles:\t%s\n", helpers.ValueOrNone(strings.Join(roles, ",")))
+	w.writeMap(0, "Labels", obj.GetLabels(), "=")
+	w.writeMap(0, "Annotations", obj.GetAnnotations(), ": ")
+	w.writeCreationTimestamp(obj)
+	var taints []string
+	for _, taint := range helpers.MapsAt(obj.Object, "spec", "taints") {
+		t := helpers.StringAt(taint, "key")
+		if value := helpers.StringAt(taint, "value"); value != "" {
+			t += "=" + value
+		}
+		taints = append(taints, t+":"+helpers.StringAt(taint, "effect"))
+	}
+	w.writeList(0, "Taints", taints)
+	unschedulable, _, _ := unstructured.NestedBool(obj.Object, "spec", "unschedulable")
+	w.Write(0, "Unschedulable:\t%v\n", unschedulable)
+	w.writeConditions(obj, "type", "status", "lastHeartbeatTime", "lastTransitionTime", "reason", "message")
+	w.Write(0, "Addresses:\n")
+	for _, address := range helpers.MapsAt(obj.Object, "status", "addresses") {
+		w.Write(1, "%s:\t%s\n", helpers.StringAt(address, "type"), helpers.StringAt(address, "address"))
+	}
+	for _, resources := range []string{"capacity", "allocatable"} {
+		if values := stringMap(obj.Object, "status", resources); len(values) > 0 {
+			w.Write(0, "%s:\n", labelFor(resources))
+			for _, key := range slices.Sorted(maps.Keys(values)) {
+				w.Write(1, "%s:\t%s\n", key, values[key])
+			}
+		}
+	}
+	if info := stringMap(obj.Object, "status", "nodeInfo"); len(info) > 0 {
+		w.Write(0, "System Info:\n")
+		for _, field := range []string{"machineID", "systemUUID", "bootID", "kernelVersion", "osImage", "containerRuntimeVersion", "kubeletVersion", "kubeProxyVersion", "operatingSystem", "architecture"} {
+			w.Write(1, "%s:\t%s\n", nodeInfoLabels[field], info[field])
+		}
+	}
+	if podCIDRs, _, _ := unstructured.NestedStringSlice(obj.Object, "spec", "podCIDRs"); len(podCIDRs) > 0 {
+		w.Write(0, "PodCIDR:\t%s\n", helpers.StringAt(obj.Object, "spec", "podCIDR"))
+		w.Write(0, "PodCIDRs:\t%s\n", strings.Join(podCIDRs, ","))
+	}
+	if providerID := helpers.StringAt(obj.Object, "spec", "providerID"); providerID != "" {
+		w.Write(0, "ProviderID:\t%s\n", providerID)
+	}
+}
+
+var nodeInfoLabels = map[string]string{
+	"machineID":               "Machine ID",
+	"systemUUID":              "System UUID",
+	"bootID":                  "Boot ID",
+	"kernelVersion":           "Kernel Version",
+	"osImage":                 "OS Image",
+	"containerRuntimeVersion": "Container Runtime Version",
+	"kubeletVersion":          "Kubelet Version",
+	"kubeProxyVersion":        "Kube-Proxy Version",
+	"operatingSystem":         "Operating System",
+	"architecture":            "Architecture",
+}
+
+func describePersistentVolumeClaim(w *prefixWriter, obj *unstructured.Unstructured) {
+	w.Write(0, "Name:\t%s\n", obj.GetName())
+	w.Write(0, "Namespace:\t%s\n", obj.GetNamespace())
+	w.Write(0, "StorageClass:\t%s\n", helpers.StringAt(obj.Object, "spec", "storageClassName"))
+	w.Write(0, "Status:\t%s\n", helpers.StringAt(obj.Object, "status", "phase"))
+	w.Write(0, "Volume:\t%s\n", helpers.StringAt(obj.Object, "spec", "volumeName"))
+	w.writeMap(0, "Labels", obj.GetLabels(), "=")
+	w.writeMap(0, "Annotations", obj.GetAnnotations(), ": ")
+	w.Write(0, "Finalizers:\t[%s]\n", strings.Join(obj.GetFinalizers(), " "))
+	w.Write(0, "Capacity:\t%s\n", helpers.StringAt(obj.Object, "status", "capacity", "storage"))
+	accessModes, _, _ := unstructured.NestedStringSlice(obj.Object, "status", "accessModes")
+	w.Write(0, "Access Modes:\t%s\n", strings.Join(accessModes, ","))
+	w.Write(0, "VolumeMode:\t%s\n", helpers.StringAt(obj.Object, "spec", "volumeMode"))
+}
+
+func describeClusterOperator(w *prefixWriter, obj *unstructured.Unstructured) {
+	w.writeMetadata(obj)
+	w.writeCreationTimestamp(obj)
+	w.Write(0, "Versions:\n")
+	for _, version := range helpers.MapsAt(obj.Object, "status", "versions") {
+		w.Write(1, "%s:\t%s\n", helpers.StringAt(version, "name"), helpers.StringAt(version, "version"))
+	}
+	w.writeConditions(obj, "type", "status", "lastTransitionTime", "reason", "message")
+	related := helpers.MapsAt(obj.Object, "status", "relatedObjects")
+	if len(related) == 0 {
+		w.Write(0, "Related Objects:\t<none>\n")
+		return
+	}
+	w.Write(0, "Related Objects:\n")
+	w.Write(1, "Group\tResource\tNamespace\tName\n")
+	w.Write(1, "-----\t--------\t---------\t----\n")
+	for _, object := range related {
+		w.Write(1, "%s\t%s\t%s\t%s\n", helpers.StringAt(object, "group"), helpers.StringAt(object, "resource"), helpers.StringAt(object, "namespace"), helpers.StringAt(object, "name"))
+	}
+}
+
+func describeMachineConfigPool(w *prefixWriter, obj *unstructured.Unstructured) {
+	w.writeMetadata(obj)
+	w.writeCreationTimestamp(obj)
+	w.Write(0, "Configuration:\t%s\n", helpers.ValueOrNone(helpers.StringAt(obj.Object, "status", "configuration", "name")))
+	paused, _, _ := unstructured.NestedBool(obj.Object, "spec", "paused")
+	w.Write(0, "Paused:\t%v\n", paused)
+	maxUnavailable, found, _ := unstructured.NestedFieldNoCopy(obj.Object, "spec", "maxUnavailable")
+	if !found {
+		maxUnavailable = 1
+	}
+	w.Write(0, "Max Unavailable:\t%v\n", maxUnavailable)
+	w.writeMap(0, "Node Selector", stringMap(obj.Object, "spec", "nodeSelector", "matchLabels"), "=")
+	w.writeMap(0, "Machine Config Selector", stringMap(obj.Object, "spec", "machineConfigSelector", "matchLabels"), "=")
+	for _, field := range []string{"machineCount", "readyMachineCount", "updatedMachineCount", "unavailableMachineCount", "degradedMachineCount"} {
+		count, _, _ := unstructured.NestedInt64(obj.Object, "status", field)
+		w.Write(0, "%s:\t%d\n", labelFor(field), count)
+	}
+	w.writeConditions(obj, "type", "status", "lastTransitionTime", "reason", "message")
+}
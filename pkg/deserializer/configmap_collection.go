@@ -17,10 +17,10 @@ package deserializer
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
 
-	log "github.com/sirupsen/logrus"
-
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -64,20 +64,24 @@ func (c *ConfigMapData) Upsert(namespace, name, key, value string) {
 	}
 }
 
-func (c *ConfigMapData) Flatten() []unstructured.Unstructured {
+// Flatten returns the collected ConfigMaps ordered by namespace and name
+func (c *ConfigMapData) Flatten() ([]unstructured.Unstructured, error) {
 	out := []unstructured.Unstructured{}
-	for namespace, nsContent := range c.data {
-		for name := range nsContent {
+	for _, namespace := range slices.Sorted(maps.Keys(c.data)) {
+		for _, name := range slices.Sorted(maps.Keys(c.data[namespace])) {
 			data := c.data[namespace][name]
-			object := wrapConfigMap(name, namespace, data)
+			object, err := wrapConfigMap(name, namespace, data)
+			if err != nil {
+				return nil, err
+			}
 			out = append(out, *object)
 		}
 	}
-	return out
+	return out, nil
 }
 
 // insights stores configmap data as plain files, so we re-construct them as configmaps and convert to unstructured again as per readResource api
-func wrapConfigMap(name, namespace string, data map[string]string) *unstructured.Unstructured {
+func wrapConfigMap(name, namespace string, data map[string]string) (*unstructured.Unstructured, error) {
 	u := &unstructured.Unstructured{}
 	u.GetObjectKind().SetGroupVersionKind(u.GetObjectKind().GroupVersionKind())
 	newObject := &v1.ConfigMap{
@@ -93,10 +97,10 @@ func wrapConfigMap(name, namespace string, data map[string]string) *unstructured
 	}
 	result, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&newObject)
 	if err != nil {
-		log.Fatal(err)
+		return nil, fmt.Errorf("unable to convert ConfigMap %s/%s to unstructured: %w", namespace, name, err)
 	}
 	u.SetUnstructuredContent(result)
-	return u
+	return u, nil
 }
 
 func configMapFromFilename(tarFilePath string) (name, namespace, key string, err error) {
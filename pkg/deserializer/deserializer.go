@@ -39,34 +39,55 @@ func NewInsightsDeserializer(o ...InsightsDeserializerOption) *InsightsDeseriali
 	return result
 }
 
+// WithApiVersion sets the apiVersion for objects lacking one, taking precedence over the known types table
 func WithApiVersion(apiVersion string) InsightsDeserializerOption {
 	return func(id *InsightsDeserializer) {
-		if apiVersion == "" {
-			apiVersion = MissingTypeMetaFieldValue
+		if apiVersion != "" {
+			log.Debugf("overriding apiVersion with %s\n", apiVersion)
 		}
-		log.Debugf("overriding apiVersion with %s\n", apiVersion)
 		id.missingApiVersion = apiVersion
 	}
 }
 
+// WithKind sets the kind for objects lacking one, taking precedence over the known types table
 func WithKind(kind string) InsightsDeserializerOption {
 	return func(id *InsightsDeserializer) {
-		if kind == "" {
-			kind = MissingTypeMetaFieldValue
+		if kind != "" {
+			log.Debugf("overriding kind with %s\n", kind)
 		}
-		log.Debugf("overriding kind with %s\n", kind)
 		id.missingKind = kind
 	}
 }
 
 func (id *InsightsDeserializer) JsonToUnstructed(raw []byte) (*unstructured.Unstructured, error) {
+	return id.jsonToUnstructed(raw, id.missingKind, id.missingApiVersion)
+}
+
+// JsonToUnstructedFromPath deserializes a file from the archive, deriving missing TypeMeta fields from its path
+// when not overridden. Types not found in the known types table get dummy values.
+func (id *InsightsDeserializer) JsonToUnstructedFromPath(filename string, raw []byte) (*unstructured.Unstructured, error) {
+	kind, apiVersion := id.missingKind, id.missingApiVersion
+	if gvk, ok := KnownTypeForPath(filename); ok {
+		if kind == "" {
+			kind = gvk.Kind
+		}
+		if apiVersion == "" {
+			apiVersion = gvk.GroupVersion().String()
+		}
+	} else {
+		log.Tracef("no known type for '%s'", filename)
+	}
+	return id.jsonToUnstructed(raw, kind, apiVersion)
+}
+
+func (id *InsightsDeserializer) jsonToUnstructed(raw []byte, kind, apiVersion string) (*unstructured.Unstructured, error) {
 	result := &unstructured.Unstructured{}
 	// First, try to unmarshal the raw json into an unstructured
 	if err := result.UnmarshalJSON(raw); err != nil {
 		// insights removes several typeMeta fields (Kind, apiVersion), eg:
 		// https://github.com/openshift/insights-operator/blob/master/docs/insights-archive-sample/config/pod/openshift-insights/insights-operator-65bcbd8bbf-n5xcr.json
 		// this causes unmarshal to fail, so try to insert fixup values for these fields and retry to unmarshal
-		if fixed, fixErr := insertTypeMeta(raw, id.missingKind, id.missingApiVersion); fixErr != nil {
+		if fixed, fixErr := insertTypeMeta(raw, kind, apiVersion); fixErr != nil {
 			return nil, fixErr
 		} else {
 			log.Trace("Trying to unmarshal after fixing missing TypeMeta fields")
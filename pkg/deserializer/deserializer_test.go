@@ -88,3 +88,121 @@ func TestInsertTypeMeta(t *testing.T) {
 		})
 	}
 }
+
+func TestKnownTypeForPath(t *testing.T) {
+	tests := []struct {
+		name, in             string
+		expectedApiVersion   string
+		expectedKind         string
+		expectedUnrecognized bool
+	}{
+		{
+			name:               "namespaced core resource",
+			in:                 "config/pod/openshift-multus/multus-sns4n.json",
+			expectedApiVersion: "v1",
+			expectedKind:       "Pod",
+		},
+		{
+			name:               "cluster-scoped resource",
+			in:                 "config/node/master-0.json",
+			expectedApiVersion: "v1",
+			expectedKind:       "Node",
+		},
+		{
+			name:               "plural resource type",
+			in:                 "config/machineconfigs/00-master.json",
+			expectedApiVersion: "machineconfiguration.openshift.io/v1",
+			expectedKind:       "MachineConfig",
+		},
+		{
+			name:               "storage resource",
+			in:                 "config/storage/storageclasses/standard-csi.json",
+			expectedApiVersion: "storage.k8s.io/v1",
+			expectedKind:       "StorageClass",
+		},
+		{
+			name:               "well-known json",
+			in:                 "config/version.json",
+			expectedApiVersion: "config.openshift.io/v1",
+			expectedKind:       "ClusterVersion",
+		},
+		{
+			name:               "conditional resource",
+			in:                 "conditional/namespaces/openshift-multus/pods/multus-sns4n.json",
+			expectedApiVersion: "v1",
+			expectedKind:       "Pod",
+		},
+		{
+			name:                 "unknown resource type",
+			in:                   "config/olm_operators.json",
+			expectedUnrecognized: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := KnownTypeForPath(tc.in)
+			if ok == tc.expectedUnrecognized {
+				t.Fatalf("Expected known: %t, got: %t", !tc.expectedUnrecognized, ok)
+			}
+			if got.GroupVersion().String() != tc.expectedApiVersion && !tc.expectedUnrecognized {
+				t.Fatalf("Expected: %s, got: %s", tc.expectedApiVersion, got.GroupVersion())
+			}
+			if got.Kind != tc.expectedKind {
+				t.Fatalf("Expected: %s, got: %s", tc.expectedKind, got.Kind)
+			}
+		})
+	}
+}
+
+func TestJsonToUnstructedFromPath(t *testing.T) {
+	tests := []struct {
+		name, path, overrideApiVersion, overrideKind string
+		raw                                          []byte
+		expectedApiVersion, expectedKind             string
+	}{
+		{
+			name:               "infer TypeMeta from path",
+			path:               "config/pod/test-namespace/test-pod.json",
+			raw:                []byte(`{"metadata":{"name":"test-pod","namespace":"test-namespace"}}`),
+			expectedApiVersion: "v1",
+			expectedKind:       "Pod",
+		},
+		{
+			name:               "keep TypeMeta present in the file",
+			path:               "config/pod/test-namespace/test-pod.json",
+			raw:                []byte(`{"apiVersion":"v2","kind":"Pod","metadata":{"name":"test-pod","namespace":"test-namespace"}}`),
+			expectedApiVersion: "v2",
+			expectedKind:       "Pod",
+		},
+		{
+			name:               "overrides take precedence over the path",
+			path:               "config/pod/test-namespace/test-pod.json",
+			overrideApiVersion: "v2",
+			overrideKind:       "FakePod",
+			raw:                []byte(`{"metadata":{"name":"test-pod","namespace":"test-namespace"}}`),
+			expectedApiVersion: "v2",
+			expectedKind:       "FakePod",
+		},
+		{
+			name:               "dummy values for unknown types",
+			path:               "config/unknown/test.json",
+			raw:                []byte(`{"metadata":{"name":"test"}}`),
+			expectedApiVersion: MissingTypeMetaFieldValue,
+			expectedKind:       MissingTypeMetaFieldValue,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			id := NewInsightsDeserializer(WithApiVersion(tc.overrideApiVersion), WithKind(tc.overrideKind))
+			got, err := id.JsonToUnstructedFromPath(tc.path, tc.raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.GetAPIVersion() != tc.expectedApiVersion || got.GetKind() != tc.expectedKind {
+				t.Fatalf("Expected: %s/%s, got: %s/%s", tc.expectedApiVersion, tc.expectedKind, got.GetAPIVersion(), got.GetKind())
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package deserializer
+
+import (
+	"path"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// knownTypes maps the resource type as found in the archive path (e.g. config/pod/<namespace>/<name>.json)
+// to the resource's GroupVersionKind, as insights strips the TypeMeta of most resources
+var knownTypes = map[string]schema.GroupVersionKind{
+	// core
+	"pod":                   {Version: "v1", Kind: "Pod"},
+	"node":                  {Version: "v1", Kind: "Node"},
+	"namespace":             {Version: "v1", Kind: "Namespace"},
+	"service":               {Version: "v1", Kind: "Service"},
+	"persistentvolume":      {Version: "v1", Kind: "PersistentVolume"},
+	"persistentvolumeclaim": {Version: "v1", Kind: "PersistentVolumeClaim"},
+	"configmap":             {Version: "v1", Kind: "ConfigMap"},
+	"event":                 {Version: "v1", Kind: "Event"},
+	// kubernetes API groups
+	"deployment":                     {Group: "apps", Version: "v1", Kind: "Deployment"},
+	"daemonset":                      {Group: "apps", Version: "v1", Kind: "DaemonSet"},
+	"statefulset":                    {Group: "apps", Version: "v1", Kind: "StatefulSet"},
+	"replicaset":                     {Group: "apps", Version: "v1", Kind: "ReplicaSet"},
+	"storageclass":                   {Group: "storage.k8s.io", Version: "v1", Kind: "StorageClass"},
+	"csidriver":                      {Group: "storage.k8s.io", Version: "v1", Kind: "CSIDriver"},
+	"pdb":                            {Group: "policy", Version: "v1", Kind: "PodDisruptionBudget"},
+	"certificatesigningrequest":      {Group: "certificates.k8s.io", Version: "v1", Kind: "CertificateSigningRequest"},
+	"crd":                            {Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition"},
+	"validatingwebhookconfiguration": {Group: "admissionregistration.k8s.io", Version: "v1", Kind: "ValidatingWebhookConfiguration"},
+	"mutatingwebhookconfiguration":   {Group: "admissionregistration.k8s.io", Version: "v1", Kind: "MutatingWebhookConfiguration"},
+	// cluster-wide configuration (config/<type>.json)
+	"clusteroperator": {Group: "config.openshift.io", Version: "v1", Kind: "ClusterOperator"},
+	"version":         {Group: "config.openshift.io", Version: "v1", Kind: "ClusterVersion"},
+	"apiserver":       {Group: "config.openshift.io", Version: "v1", Kind: "APIServer"},
+	"authentication":  {Group: "config.openshift.io", Version: "v1", Kind: "Authentication"},
+	"build":           {Group: "config.openshift.io", Version: "v1", Kind: "Build"},
+	"console":         {Group: "config.openshift.io", Version: "v1", Kind: "Console"},
+	"dns":             {Group: "config.openshift.io", Version: "v1", Kind: "DNS"},
+	"featuregate":     {Group: "config.openshift.io", Version: "v1", Kind: "FeatureGate"},
+	"image":           {Group: "config.openshift.io", Version: "v1", Kind: "Image"},
+	"infrastructure":  {Group: "config.openshift.io", Version: "v1", Kind: "Infrastructure"},
+	"ingress":         {Group: "config.openshift.io", Version: "v1", Kind: "Ingress"},
+	"network":         {Group: "config.openshift.io", Version: "v1", Kind: "Network"},
+	"oauth":           {Group: "config.openshift.io", Version: "v1", Kind: "OAuth"},
+	"project":         {Group: "config.openshift.io", Version: "v1", Kind: "Project"},
+	"proxy":           {Group: "config.openshift.io", Version: "v1", Kind: "Proxy"},
+	"scheduler":       {Group: "config.openshift.io", Version: "v1", Kind: "Scheduler"},
+	// openshift API groups
+	"ingresscontroller":  {Group: "operator.openshift.io", Version: "v1", Kind: "IngressController"},
+	"machineconfig":      {Group: "machineconfiguration.openshift.io", Version: "v1", Kind: "MachineConfig"},
+	"machineconfigpool":  {Group: "machineconfiguration.openshift.io", Version: "v1", Kind: "MachineConfigPool"},
+	"machine":            {Group: "machine.openshift.io", Version: "v1beta1", Kind: "Machine"},
+	"machineset":         {Group: "machine.openshift.io", Version: "v1beta1", Kind: "MachineSet"},
+	"machinehealthcheck": {Group: "machine.openshift.io", Version: "v1beta1", Kind: "MachineHealthCheck"},
+	"machineautoscaler":  {Group: "autoscaling.openshift.io", Version: "v1beta1", Kind: "MachineAutoscaler"},
+	"hostsubnet":         {Group: "network.openshift.io", Version: "v1", Kind: "HostSubnet"},
+	"netnamespace":       {Group: "network.openshift.io", Version: "v1", Kind: "NetNamespace"},
+}
+
+// KnownType returns the GroupVersionKind of a resource type as named in the archive, either singular or plural
+func KnownType(resourceType string) (schema.GroupVersionKind, bool) {
+	for _, candidate := range []string{resourceType, strings.TrimSuffix(resourceType, "s"), strings.TrimSuffix(resourceType, "es")} {
+		if gvk, ok := knownTypes[candidate]; ok {
+			return gvk, true
+		}
+	}
+	return schema.GroupVersionKind{}, false
+}
+
+// KnownTypeForPath returns the GroupVersionKind of the resource stored at path in the archive
+func KnownTypeForPath(filename string) (schema.GroupVersionKind, bool) {
+	resourceType := resourceTypeFromPath(filename)
+	if resourceType == "" {
+		return schema.GroupVersionKind{}, false
+	}
+	return KnownType(resourceType)
+}
+
+// derive the resource type from the location of a file in the archive:
+// config/<type>.json, config/<type>/[<namespace>/]<name>.json, config/storage/<type>/... or conditional/namespaces/<namespace>/<type>/<name>.json
+func resourceTypeFromPath(filename string) string {
+	parts := strings.Split(strings.Trim(filename, "/"), "/")
+	switch {
+	case parts[0] == "config":
+		parts = parts[1:]
+		if len(parts) > 1 && parts[0] == "storage" {
+			parts = parts[1:]
+		}
+		switch len(parts) {
+		case 0:
+			return ""
+		case 1:
+			return strings.TrimSuffix(parts[0], path.Ext(parts[0]))
+		default:
+			return parts[0]
+		}
+	case parts[0] == "conditional" && len(parts) > 3 && parts[1] == "namespaces":
+		return parts[3]
+	}
+	return ""
+}
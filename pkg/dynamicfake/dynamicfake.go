@@ -0,0 +1,93 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package dynamicfake provides read-only client-go clients over an insights archive,
+// so code written against dynamic.Interface or discovery.DiscoveryInterface runs unmodified against insights data.
+//
+// The clients talk to the API served by `in2un serve`, in process and without opening a port:
+//
+//	ir, err := reader.NewInsightsReader("insights.tar.gz")
+//	...
+//	c, err := dynamicfake.New(ir)
+//	...
+//	pods, err := c.Dynamic.Resource(corev1.SchemeGroupVersion.WithResource("pods")).Namespace("openshift-etcd").List(ctx, metav1.ListOptions{LabelSelector: "app=etcd"})
+//
+// Writes and watches fail with a MethodNotAllowed error (apierrors.IsMethodNotSupported).
+package dynamicfake
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/bverschueren/in2un/pkg/reader"
+	"github.com/bverschueren/in2un/pkg/server"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+)
+
+// host of the in process server, requests never leave the process
+const host = "http://in2un.local"
+
+// Client holds read-only clients for the content of an insights archive
+type Client struct {
+	Dynamic   dynamic.Interface
+	Discovery discovery.DiscoveryInterface
+	// Config creates other clients for the archive, e.g. a typed clientset with kubernetes.NewForConfig
+	Config *rest.Config
+}
+
+// New returns clients for the archive read by ir, which should stay open while the clients are used
+func New(ir *reader.InsightsReader) (*Client, error) {
+	handler, err := server.NewServer(ir)
+	if err != nil {
+		return nil, err
+	}
+	config := NewConfig(handler)
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{Dynamic: dynamicClient, Discovery: discoveryClient, Config: config}, nil
+}
+
+// NewConfig returns a client config sending requests to handler in process
+func NewConfig(handler http.Handler) *rest.Config {
+	return &rest.Config{
+		Host:      host,
+		Transport: handlerTransport{handler},
+		// the archive is read from memory or disk, client side throttling only slows down
+		QPS:   -1,
+		Burst: -1,
+	}
+}
+
+// handlerTransport serves requests with a handler rather than sending them over the network
+type handlerTransport struct {
+	handler http.Handler
+}
+
+func (t handlerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rec := httptest.NewRecorder()
+	t.handler.ServeHTTP(rec, req)
+	resp := rec.Result()
+	resp.Request = req
+	return resp, nil
+}
@@ -0,0 +1,188 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package dynamicfake
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/bverschueren/in2un/internal/testutil"
+	"github.com/bverschueren/in2un/pkg/reader"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	pods             = schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	clusteroperators = schema.GroupVersionResource{Group: "config.openshift.io", Version: "v1", Resource: "clusteroperators"}
+)
+
+func newTestClient(t *testing.T) *Client {
+	files := map[string]string{
+		"config/node/master-0.json":            `{"apiVersion":"v1","kind":"Node","metadata":{"name":"master-0"},"status":{"nodeInfo":{"kubeletVersion":"v1.29.5+4b2c1e8"}}}`,
+		"config/pod/ns1/a.json":                `{"apiVersion":"v1","kind":"Pod","metadata":{"name":"a","namespace":"ns1","labels":{"app":"web"}}}`,
+		"config/pod/ns1/b.json":                `{"apiVersion":"v1","kind":"Pod","metadata":{"name":"b","namespace":"ns1"}}`,
+		"config/pod/ns2/c.json":                `{"apiVersion":"v1","kind":"Pod","metadata":{"name":"c","namespace":"ns2","labels":{"app":"web"}}}`,
+		"config/pod/ns1/logs/a/c1_current.log": "line1\nline2\n",
+		"config/clusteroperators/network.json": `{"apiVersion":"config.openshift.io/v1","kind":"ClusterOperator","metadata":{"name":"network"}}`,
+	}
+	ir, err := reader.NewInsightsReader(testutil.WriteArchive(t, files))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ir.Close() })
+	c, err := New(ir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func names(list *unstructured.UnstructuredList) []string {
+	var result []string
+	for _, item := range list.Items {
+		result = append(result, item.GetName())
+	}
+	return result
+}
+
+func TestDynamicList(t *testing.T) {
+	c := newTestClient(t)
+	tests := []struct {
+		name      string
+		gvr       schema.GroupVersionResource
+		namespace string
+		options   metav1.ListOptions
+		expected  []string
+	}{
+		{
+			name:     "all namespaces",
+			gvr:      pods,
+			expected: []string{"a", "b", "c"},
+		},
+		{
+			name:      "namespace",
+			gvr:       pods,
+			namespace: "ns1",
+			expected:  []string{"a", "b"},
+		},
+		{
+			name:     "label selector",
+			gvr:      pods,
+			options:  metav1.ListOptions{LabelSelector: "app=web"},
+			expected: []string{"a", "c"},
+		},
+		{
+			name:      "field selector",
+			gvr:       pods,
+			namespace: "ns1",
+			options:   metav1.ListOptions{FieldSelector: "metadata.name!=a"},
+			expected:  []string{"b"},
+		},
+		{
+			name:     "cluster scoped",
+			gvr:      clusteroperators,
+			expected: []string{"network"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := c.Dynamic.Resource(tc.gvr).Namespace(tc.namespace).List(context.Background(), tc.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(names(got), tc.expected) {
+				t.Fatalf("Expected: %v, got: %v", tc.expected, names(got))
+			}
+		})
+	}
+}
+
+func TestDynamicGet(t *testing.T) {
+	c := newTestClient(t)
+	ctx := context.Background()
+	got, err := c.Dynamic.Resource(pods).Namespace("ns1").Get(ctx, "a", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.GetName() != "a" || got.GetKind() != "Pod" {
+		t.Fatalf("Expected: Pod a, got: %s %s", got.GetKind(), got.GetName())
+	}
+	if _, err := c.Dynamic.Resource(pods).Namespace("ns1").Get(ctx, "z", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Fatalf("Expected: not found error, got: %v", err)
+	}
+	if err := c.Dynamic.Resource(pods).Namespace("ns1").Delete(ctx, "a", metav1.DeleteOptions{}); !apierrors.IsMethodNotSupported(err) {
+		t.Fatalf("Expected: method not supported error, got: %v", err)
+	}
+	if _, err := c.Dynamic.Resource(pods).Namespace("ns1").Create(ctx, got, metav1.CreateOptions{}); !apierrors.IsMethodNotSupported(err) {
+		t.Fatalf("Expected: method not supported error, got: %v", err)
+	}
+}
+
+func TestDiscovery(t *testing.T) {
+	c := newTestClient(t)
+	info, err := c.Discovery.ServerVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.GitVersion != "v1.29.5+4b2c1e8" {
+		t.Fatalf("Expected: v1.29.5+4b2c1e8, got: %s", info.GitVersion)
+	}
+	_, lists, err := c.Discovery.ServerGroupsAndResources()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, list := range lists {
+		for _, r := range list.APIResources {
+			got = append(got, list.GroupVersion+"/"+r.Name)
+		}
+	}
+	slices.Sort(got)
+	expected := []string{"config.openshift.io/v1/clusteroperators", "v1/nodes", "v1/pods", "v1/pods/log"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected: %v, got: %v", expected, got)
+	}
+}
+
+func TestTypedClient(t *testing.T) {
+	c := newTestClient(t)
+	clientset, err := kubernetes.NewForConfig(c.Config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pod, err := clientset.CoreV1().Pods("ns2").Get(context.Background(), "c", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pod.Labels["app"] != "web" {
+		t.Fatalf("Expected: label app=web, got: %v", pod.Labels)
+	}
+	raw, err := clientset.CoreV1().Pods("ns1").GetLogs("a", &corev1.PodLogOptions{}).DoRaw(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(raw) != "line1\nline2\n" {
+		t.Fatalf("Expected: two log lines, got: %q", raw)
+	}
+}
@@ -0,0 +1,45 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filter
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var eventTypes = []string{"Normal", "Warning"}
+
+// ByEventTypes returns the events of list with one of the given types, matched case-insensitively as kubectl does
+func ByEventTypes(list *unstructured.UnstructuredList, types []string) (*unstructured.UnstructuredList, error) {
+	if len(types) == 0 {
+		return list, nil
+	}
+	wanted := make([]string, len(types))
+	for i, t := range types {
+		idx := slices.IndexFunc(eventTypes, func(eventType string) bool { return strings.EqualFold(eventType, t) })
+		if idx < 0 {
+			return nil, fmt.Errorf("invalid event type '%s', allowed types are: %s", t, strings.Join(eventTypes, ","))
+		}
+		wanted[i] = eventTypes[idx]
+	}
+	return items(list, func(item *unstructured.Unstructured) bool {
+		eventType, _, _ := unstructured.NestedString(item.Object, "type")
+		return slices.Contains(wanted, eventType)
+	}), nil
+}
@@ -0,0 +1,57 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filter
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestByEventTypes(t *testing.T) {
+	list := generateUnstructuredList(
+		map[string]interface{}{"metadata": map[string]interface{}{"name": "backoff"}, "type": "Warning"},
+		map[string]interface{}{"metadata": map[string]interface{}{"name": "started"}, "type": "Normal"},
+		map[string]interface{}{"metadata": map[string]interface{}{"name": "untyped"}},
+	)
+	tests := []struct {
+		name        string
+		types       []string
+		expected    []string
+		expectedErr bool
+	}{
+		{name: "no types", expected: []string{"backoff", "started", "untyped"}},
+		{name: "warnings", types: []string{"Warning"}, expected: []string{"backoff"}},
+		{name: "case insensitive", types: []string{"normal", "WARNING"}, expected: []string{"backoff", "started"}},
+		{name: "invalid type", types: []string{"Error"}, expectedErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ByEventTypes(list, tc.types)
+			if tc.expectedErr {
+				if err == nil {
+					t.Fatalf("Expected: error, got: %v", names(got))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(tc.expected, names(got)) {
+				t.Fatalf("Expected: %v, got: %v", tc.expected, names(got))
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filter
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+)
+
+// ByFields returns the items of list matching a field selector on arbitrary dotted paths,
+// e.g. 'status.phase!=Running' or 'involvedObject.kind=Pod,involvedObject.name=foo'
+func ByFields(list *unstructured.UnstructuredList, selector string) (*unstructured.UnstructuredList, error) {
+	if selector == "" {
+		return list, nil
+	}
+	parsed, err := fields.ParseSelector(selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid field selector '%s': %w", selector, err)
+	}
+	requirements := parsed.Requirements()
+	return items(list, func(item *unstructured.Unstructured) bool {
+		set := fields.Set{}
+		for _, requirement := range requirements {
+			if value, ok := fieldValue(item.Object, requirement.Field); ok {
+				set[requirement.Field] = value
+			}
+		}
+		return parsed.Matches(set)
+	}), nil
+}
+
+// the value at a dotted path as a string, missing fields and fields which are not a scalar value are not found
+func fieldValue(obj map[string]interface{}, path string) (string, bool) {
+	value, found, err := unstructured.NestedFieldNoCopy(obj, strings.Split(path, ".")...)
+	if !found || err != nil {
+		return "", false
+	}
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case bool, int64, float64:
+		return fmt.Sprint(v), true
+	}
+	return "", false
+}
@@ -0,0 +1,62 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filter
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestByFields(t *testing.T) {
+	list := generateUnstructuredList(
+		map[string]interface{}{"metadata": map[string]interface{}{"name": "running", "namespace": "ns1"}, "spec": map[string]interface{}{"nodeName": "master-0", "hostNetwork": true}, "status": map[string]interface{}{"phase": "Running"}},
+		map[string]interface{}{"metadata": map[string]interface{}{"name": "pending", "namespace": "ns2"}, "status": map[string]interface{}{"phase": "Pending"}},
+		map[string]interface{}{"metadata": map[string]interface{}{"name": "event"}, "involvedObject": map[string]interface{}{"kind": "Pod", "name": "running"}, "count": int64(3)},
+	)
+	tests := []struct {
+		name, selector string
+		expected       []string
+		expectedErr    bool
+	}{
+		{name: "empty selector", selector: "", expected: []string{"running", "pending", "event"}},
+		{name: "equality", selector: "status.phase=Running", expected: []string{"running"}},
+		{name: "double equality", selector: "status.phase==Pending", expected: []string{"pending"}},
+		{name: "inequality includes missing fields", selector: "status.phase!=Running", expected: []string{"pending", "event"}},
+		{name: "metadata", selector: "metadata.namespace=ns2", expected: []string{"pending"}},
+		{name: "empty value matches missing fields", selector: "spec.nodeName=", expected: []string{"pending", "event"}},
+		{name: "boolean field", selector: "spec.hostNetwork=true", expected: []string{"running"}},
+		{name: "integer field", selector: "count=3", expected: []string{"event"}},
+		{name: "conjunction", selector: "involvedObject.kind=Pod,involvedObject.name=running", expected: []string{"event"}},
+		{name: "conjunction without match", selector: "involvedObject.kind=Pod,involvedObject.name=pending", expected: []string{}},
+		{name: "non-scalar field", selector: "status=Running", expected: []string{}},
+		{name: "invalid selector", selector: "status.phase", expectedErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ByFields(list, tc.selector)
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("Expected error: %t, got: %v", tc.expectedErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(names(got), tc.expected) {
+				t.Fatalf("Expected: %v, got: %v", tc.expected, names(got))
+			}
+		})
+	}
+}
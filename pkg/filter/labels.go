@@ -0,0 +1,48 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filter
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// ByLabels returns the items of list matching a label selector, e.g. 'app=etcd', 'tier in (frontend,backend)' or '!key'
+func ByLabels(list *unstructured.UnstructuredList, selector string) (*unstructured.UnstructuredList, error) {
+	if selector == "" {
+		return list, nil
+	}
+	parsed, err := labels.Parse(selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid label selector '%s': %w", selector, err)
+	}
+	return items(list, func(item *unstructured.Unstructured) bool {
+		return parsed.Matches(labels.Set(item.GetLabels()))
+	}), nil
+}
+
+// return a copy of list with only the items for which match returns true
+func items(list *unstructured.UnstructuredList, match func(*unstructured.Unstructured) bool) *unstructured.UnstructuredList {
+	result := &unstructured.UnstructuredList{Object: list.Object}
+	for i := range list.Items {
+		if match(&list.Items[i]) {
+			result.Items = append(result.Items, list.Items[i])
+		}
+	}
+	return result
+}
@@ -0,0 +1,77 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filter
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func generateUnstructuredList(objects ...map[string]interface{}) *unstructured.UnstructuredList {
+	list := &unstructured.UnstructuredList{Object: map[string]interface{}{"kind": "List", "apiVersion": "v1"}}
+	for _, obj := range objects {
+		list.Items = append(list.Items, unstructured.Unstructured{Object: obj})
+	}
+	return list
+}
+
+func names(list *unstructured.UnstructuredList) []string {
+	result := []string{}
+	for _, item := range list.Items {
+		result = append(result, item.GetName())
+	}
+	return result
+}
+
+func TestByLabels(t *testing.T) {
+	list := generateUnstructuredList(
+		map[string]interface{}{"metadata": map[string]interface{}{"name": "etcd-0", "labels": map[string]interface{}{"app": "etcd", "tier": "control-plane"}}},
+		map[string]interface{}{"metadata": map[string]interface{}{"name": "master-0", "labels": map[string]interface{}{"node-role.kubernetes.io/master": ""}}},
+		map[string]interface{}{"metadata": map[string]interface{}{"name": "unlabeled"}},
+	)
+	tests := []struct {
+		name, selector string
+		expected       []string
+		expectedErr    bool
+	}{
+		{name: "empty selector", selector: "", expected: []string{"etcd-0", "master-0", "unlabeled"}},
+		{name: "equality", selector: "app=etcd", expected: []string{"etcd-0"}},
+		{name: "inequality", selector: "app!=etcd", expected: []string{"master-0", "unlabeled"}},
+		{name: "existence", selector: "node-role.kubernetes.io/master", expected: []string{"master-0"}},
+		{name: "non-existence", selector: "!app", expected: []string{"master-0", "unlabeled"}},
+		{name: "set-based", selector: "tier in (control-plane,worker)", expected: []string{"etcd-0"}},
+		{name: "set-based exclusion", selector: "tier notin (control-plane)", expected: []string{"master-0", "unlabeled"}},
+		{name: "multiple requirements", selector: "app=etcd,tier=worker", expected: []string{}},
+		{name: "invalid selector", selector: "app in (etcd", expectedErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ByLabels(list, tc.selector)
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("Expected error: %t, got: %v", tc.expectedErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(names(got), tc.expected) {
+				t.Fatalf("Expected: %v, got: %v", tc.expected, names(got))
+			}
+		})
+	}
+}
@@ -17,6 +17,7 @@ package helpers
 
 import (
 	"slices"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -42,17 +43,41 @@ func Plural(singular string) string {
 	return singular + `s?`
 }
 
+// ResourceForKind returns the lower case plural resource name of a kind, e.g. ingresses, proxies or pods
+func ResourceForKind(kind string) string {
+	singular := strings.ToLower(kind)
+	switch {
+	case singular == "":
+		return ""
+	case strings.HasSuffix(singular, "s"), strings.HasSuffix(singular, "x"), strings.HasSuffix(singular, "ch"), strings.HasSuffix(singular, "sh"):
+		return singular + "es"
+	case len(singular) > 1 && strings.HasSuffix(singular, "y") && !strings.ContainsAny(singular[len(singular)-2:len(singular)-1], "aeiou"):
+		return strings.TrimSuffix(singular, "y") + "ies"
+	}
+	return singular + "s"
+}
+
+// Aliases maps short names and alternative names of resource types to the type as named in the archive, as a static map as best effort
+var Aliases = map[string]string{
+	"mc":             "machineconfig",
+	"mcp":            "machineconfigpool",
+	"cm":             "configmap",
+	"co":             "clusteroperator",
+	"ns":             "namespace",
+	"pv":             "persistentvolume",
+	"pvc":            "persistentvolumeclaim",
+	"sc":             "storageclass",
+	"clusterversion": "version",
+	"po":             "pod",
+	"deploy":         "deployment",
+	"rs":             "replicaset",
+	"ds":             "daemonset",
+	"sts":            "statefulset",
+}
+
 func Unalias(alias string) string {
 	log.Debug("Using static alias map as best effort")
-	aliases := map[string]string{
-		"mc":  "machineconfig",
-		"cm":  "configmap",
-		"co":  "clusteroperator",
-		"ns":  "namespace",
-		"pv":  "persistentvolume",
-		"pvc": "persistentvolumeclaim",
-	}
-	if unalias, ok := aliases[alias]; ok {
+	if unalias, ok := Aliases[alias]; ok {
 		return unalias
 	}
 	return alias
@@ -90,6 +90,50 @@ func TestPlural(t *testing.T) {
 	}
 }
 
+func TestResourceForKind(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       string
+		expected string
+	}{
+		{
+			name:     "Pod",
+			in:       "Pod",
+			expected: "pods",
+		},
+		{
+			name:     "StorageClass",
+			in:       "StorageClass",
+			expected: "storageclasses",
+		},
+		{
+			name:     "Ingress",
+			in:       "Ingress",
+			expected: "ingresses",
+		},
+		{
+			name:     "Proxy",
+			in:       "Proxy",
+			expected: "proxies",
+		},
+		{
+			name:     "Gateway",
+			in:       "Gateway",
+			expected: "gateways",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ResourceForKind(tc.in)
+
+			if got != tc.expected {
+				t.Fatalf("Expected: %s, got: %s", tc.expected, got)
+			}
+		})
+	}
+}
+
 func TestUnalias(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -126,6 +170,21 @@ func TestUnalias(t *testing.T) {
 			in:       "pvc",
 			expected: "persistentvolumeclaim",
 		},
+		{
+			name:     "static unalias for MachineConfigPool",
+			in:       "mcp",
+			expected: "machineconfigpool",
+		},
+		{
+			name:     "static unalias for ClusterVersion",
+			in:       "clusterversion",
+			expected: "version",
+		},
+		{
+			name:     "no alias",
+			in:       "pods",
+			expected: "pods",
+		},
 	}
 
 	for _, tc := range tests {
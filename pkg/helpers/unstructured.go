@@ -0,0 +1,75 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package helpers
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+// StringAt returns the string at fields, empty when missing or not a string
+func StringAt(obj map[string]interface{}, fields ...string) string {
+	s, _, _ := unstructured.NestedString(obj, fields...)
+	return s
+}
+
+// MapsAt returns the maps in the list at fields, skipping non-map elements
+func MapsAt(obj map[string]interface{}, fields ...string) []map[string]interface{} {
+	list, _, _ := unstructured.NestedSlice(obj, fields...)
+	var result []map[string]interface{}
+	for _, item := range list {
+		if m, ok := item.(map[string]interface{}); ok {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+// ValueOrNone returns s, or <none> when empty as kubectl prints missing values
+func ValueOrNone(s string) string {
+	if s == "" {
+		return "<none>"
+	}
+	return s
+}
+
+// EventTime returns the last time an event occurred, falling back to its first occurrence or creation
+func EventTime(event *unstructured.Unstructured) time.Time {
+	for _, field := range []string{"lastTimestamp", "eventTime", "firstTimestamp"} {
+		if t, err := time.Parse(time.RFC3339, StringAt(event.Object, field)); err == nil {
+			return t
+		}
+	}
+	return event.GetCreationTimestamp().Time
+}
+
+// EventAge returns the time since an event was last seen as kubectl shows it, e.g. '5m (x3 over 1h)' for repeated events
+func EventAge(event *unstructured.Unstructured, now time.Time) string {
+	last := EventTime(event)
+	if last.IsZero() {
+		return "<unknown>"
+	}
+	age := duration.HumanDuration(now.Sub(last))
+	count, _, _ := unstructured.NestedInt64(event.Object, "count")
+	first, err := time.Parse(time.RFC3339, StringAt(event.Object, "firstTimestamp"))
+	if count > 1 && err == nil {
+		return fmt.Sprintf("%s (x%d over %s)", age, count, duration.HumanDuration(now.Sub(first)))
+	}
+	return age
+}
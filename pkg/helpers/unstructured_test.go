@@ -0,0 +1,67 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package helpers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bverschueren/in2un/internal/testutil"
+)
+
+func TestEventAge(t *testing.T) {
+	now := time.Date(2024, 10, 16, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		raw      string
+		expected string
+	}{
+		{
+			name:     "last seen",
+			raw:      `{"apiVersion":"v1","kind":"Event","lastTimestamp":"2024-10-16T11:55:00Z"}`,
+			expected: "5m",
+		},
+		{
+			name:     "repeated",
+			raw:      `{"apiVersion":"v1","kind":"Event","lastTimestamp":"2024-10-16T11:55:00Z","firstTimestamp":"2024-10-16T11:00:00Z","count":3}`,
+			expected: "5m (x3 over 60m)",
+		},
+		{
+			name:     "event time",
+			raw:      `{"apiVersion":"v1","kind":"Event","eventTime":"2024-10-16T11:58:00Z"}`,
+			expected: "2m",
+		},
+		{
+			name:     "creation as fallback",
+			raw:      `{"apiVersion":"v1","kind":"Event","metadata":{"creationTimestamp":"2024-10-16T10:00:00Z"}}`,
+			expected: "120m",
+		},
+		{
+			name:     "no time",
+			raw:      `{"apiVersion":"v1","kind":"Event","metadata":{"name":"e0"}}`,
+			expected: "<unknown>",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			event := testutil.Unstructured(t, tc.raw)
+			got := EventAge(&event, now)
+			if got != tc.expected {
+				t.Fatalf("Expected: %s, got: %s", tc.expected, got)
+			}
+		})
+	}
+}
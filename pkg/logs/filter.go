@@ -0,0 +1,164 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package logs
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// lines longer than this are rejected rather than buffered indefinitely
+const maxLineLength = 1024 * 1024
+
+// Filter selects the lines of an archived container log
+type Filter struct {
+	// Tail keeps only the last lines, all lines when negative
+	Tail int64
+	// Since drops the lines logged before this time, no lines are dropped when zero
+	Since time.Time
+	// Grep keeps only the lines matching the expression, all lines when nil
+	Grep *regexp.Regexp
+	// Reference completes timestamps lacking a year, as klog writes them, usually the gather time of the archive
+	Reference time.Time
+}
+
+// IsZero tells whether the filter keeps all lines
+func (f Filter) IsZero() bool {
+	return f.Tail < 0 && f.Since.IsZero() && f.Grep == nil
+}
+
+// Copy writes the lines of r selected by the filter to w, each preceded by prefix.
+// Lines without a timestamp, e.g. those of a stack trace, belong to the last timestamped line before them.
+func (f Filter) Copy(w io.Writer, r io.Reader, prefix string) error {
+	return f.CopySources(w, Source{Reader: r, Prefix: prefix})
+}
+
+// Source is one of the logs of a container, its lines are written preceded by Prefix
+type Source struct {
+	Reader io.Reader
+	Prefix string
+}
+
+// CopySources writes the selected lines of the sources to w in the order given, as if they were a single log:
+// Tail keeps the last lines of all sources together.
+func (f Filter) CopySources(w io.Writer, sources ...Source) error {
+	var tail []string
+	if f.Tail >= 0 {
+		tail = make([]string, 0, min(f.Tail, 4096))
+	}
+	for _, source := range sources {
+		var last time.Time
+		scanner := bufio.NewScanner(source.Reader)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxLineLength)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if !f.Since.IsZero() {
+				if t, ok := Timestamp(line, f.Reference); ok {
+					last = t
+				}
+				if last.IsZero() || last.Before(f.Since) {
+					continue
+				}
+			}
+			if f.Grep != nil && !f.Grep.MatchString(line) {
+				continue
+			}
+			switch {
+			case f.Tail < 0:
+				if _, err := fmt.Fprintf(w, "%s%s\n", source.Prefix, line); err != nil {
+					return err
+				}
+			case f.Tail == 0:
+			case int64(len(tail)) < f.Tail:
+				tail = append(tail, source.Prefix+line)
+			default:
+				tail = append(tail[1:], source.Prefix+line)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+	}
+	for _, line := range tail {
+		if _, err := fmt.Fprintf(w, "%s\n", line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var (
+	// klog header, e.g. 'I1016 12:00:00.123456       1 controller.go:42] message'
+	klogTimestamp = regexp.MustCompile(`^[IWEF](\d{4} \d{2}:\d{2}:\d{2}\.\d{6})\s`)
+	// logfmt time field, e.g. 'time="2024-10-16T12:00:00Z" level=info msg="message"'
+	logfmtTimestamp = regexp.MustCompile(`(?:^|\s)(?:time|ts|timestamp)="?([0-9][^"\s]*)"?`)
+)
+
+// JSON fields holding the time of structured log lines, as written by zap, logr and logrus
+var jsonTimestampFields = []string{"ts", "time", "timestamp", "@timestamp"}
+
+// Timestamp returns the time a log line was written, parsing RFC3339, klog, logfmt and JSON log lines.
+// klog timestamps lack the year, they get the year of reference and are assumed not to be logged after it.
+func Timestamp(line string, reference time.Time) (time.Time, bool) {
+	if field, _, _ := strings.Cut(line, " "); len(field) >= len("2006-01-02T15:04:05Z") {
+		if t, err := time.Parse(time.RFC3339Nano, field); err == nil {
+			return t, true
+		}
+	}
+	if m := klogTimestamp.FindStringSubmatch(line); m != nil {
+		t, err := time.Parse("0102 15:04:05.000000", m[1])
+		if err != nil {
+			return time.Time{}, false
+		}
+		t = t.AddDate(reference.Year(), 0, 0)
+		// a log line written in december gathered in january
+		if t.After(reference.Add(24 * time.Hour)) {
+			t = t.AddDate(-1, 0, 0)
+		}
+		return t, true
+	}
+	if strings.HasPrefix(line, "{") {
+		var fields map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &fields); err != nil {
+			return time.Time{}, false
+		}
+		for _, name := range jsonTimestampFields {
+			switch value := fields[name].(type) {
+			case string:
+				if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+					return t, true
+				}
+			case float64:
+				// seconds since the epoch
+				seconds, fraction := math.Modf(value)
+				return time.Unix(int64(seconds), int64(fraction*1e9)).UTC(), true
+			}
+		}
+		return time.Time{}, false
+	}
+	if m := logfmtTimestamp.FindStringSubmatch(line); m != nil {
+		if t, err := time.Parse(time.RFC3339Nano, m[1]); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
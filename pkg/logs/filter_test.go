@@ -0,0 +1,127 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package logs
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimestamp(t *testing.T) {
+	reference := time.Date(2024, 10, 16, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		line       string
+		reference  time.Time
+		expected   time.Time
+		expectedOk bool
+	}{
+		{name: "rfc3339", line: "2024-10-16T11:00:00.123Z starting", expected: time.Date(2024, 10, 16, 11, 0, 0, 123000000, time.UTC), expectedOk: true},
+		{name: "klog", line: "I1016 11:30:00.000001       1 controller.go:42] synced", reference: reference, expected: time.Date(2024, 10, 16, 11, 30, 0, 1000, time.UTC), expectedOk: true},
+		{name: "klog before new year", line: "E1231 23:59:00.000000       1 controller.go:42] failed", reference: time.Date(2025, 1, 1, 0, 10, 0, 0, time.UTC), expected: time.Date(2024, 12, 31, 23, 59, 0, 0, time.UTC), expectedOk: true},
+		{name: "logfmt", line: `time="2024-10-16T11:00:00Z" level=info msg="synced"`, expected: time.Date(2024, 10, 16, 11, 0, 0, 0, time.UTC), expectedOk: true},
+		{name: "json with rfc3339", line: `{"level":"info","ts":"2024-10-16T11:00:00Z","msg":"synced"}`, expected: time.Date(2024, 10, 16, 11, 0, 0, 0, time.UTC), expectedOk: true},
+		{name: "json with epoch", line: `{"level":"info","ts":1729076400.5,"msg":"synced"}`, expected: time.Date(2024, 10, 16, 11, 0, 0, 500000000, time.UTC), expectedOk: true},
+		{name: "json without time", line: `{"level":"info","msg":"synced"}`},
+		{name: "plain", line: "goroutine 1 [running]:"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := Timestamp(tc.line, tc.reference)
+			if ok != tc.expectedOk || !got.Equal(tc.expected) {
+				t.Fatalf("Expected: %s (%t), got: %s (%t)", tc.expected, tc.expectedOk, got, ok)
+			}
+		})
+	}
+}
+
+func TestFilterCopy(t *testing.T) {
+	log := strings.Join([]string{
+		"I1016 11:00:00.000000       1 main.go:1] starting",
+		"I1016 11:50:00.000000       1 main.go:2] synced",
+		"E1016 11:55:00.000000       1 main.go:3] panic",
+		"goroutine 1 [running]:",
+		"I1016 11:59:00.000000       1 main.go:4] synced",
+	}, "\n")
+	reference := time.Date(2024, 10, 16, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		filter   Filter
+		prefix   string
+		expected []string
+	}{
+		{name: "all lines", filter: Filter{Tail: -1}, expected: []string{"starting", "synced", "panic", "goroutine", "synced"}},
+		{name: "tail", filter: Filter{Tail: 2}, expected: []string{"goroutine", "synced"}},
+		{name: "no lines", filter: Filter{Tail: 0}, expected: nil},
+		{name: "since keeps untimestamped lines", filter: Filter{Tail: -1, Since: reference.Add(-6 * time.Minute), Reference: reference}, expected: []string{"panic", "goroutine", "synced"}},
+		{name: "grep", filter: Filter{Tail: -1, Grep: regexp.MustCompile(`synced|panic`)}, expected: []string{"synced", "panic", "synced"}},
+		{name: "grep and tail", filter: Filter{Tail: 1, Grep: regexp.MustCompile(`^E`)}, expected: []string{"panic"}},
+		{name: "prefix", filter: Filter{Tail: 1}, prefix: "[pod/a/c] ", expected: []string{"[pod/a/c] I1016 11:59"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			if err := tc.filter.Copy(out, strings.NewReader(log), tc.prefix); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			if out.Len() > 0 {
+				got = strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+			}
+			if len(got) != len(tc.expected) {
+				t.Fatalf("Expected: %v, got: %v", tc.expected, got)
+			}
+			for i := range got {
+				if !strings.Contains(got[i], tc.expected[i]) {
+					t.Fatalf("Expected: %v, got: %v", tc.expected, got)
+				}
+			}
+		})
+	}
+}
+
+func TestFilterCopySources(t *testing.T) {
+	sources := func() []Source {
+		return []Source{
+			{Reader: strings.NewReader("a1\na2\na3\n"), Prefix: "[a] "},
+			{Reader: strings.NewReader("b1\nb2\n"), Prefix: "[b] "},
+		}
+	}
+	tests := []struct {
+		name     string
+		filter   Filter
+		expected string
+	}{
+		{name: "all lines", filter: Filter{Tail: -1}, expected: "[a] a1\n[a] a2\n[a] a3\n[b] b1\n[b] b2\n"},
+		{name: "tail across sources", filter: Filter{Tail: 3}, expected: "[a] a3\n[b] b1\n[b] b2\n"},
+		{name: "tail within last source", filter: Filter{Tail: 1}, expected: "[b] b2\n"},
+		{name: "grep and tail", filter: Filter{Tail: 2, Grep: regexp.MustCompile(`[ab][12]`)}, expected: "[b] b1\n[b] b2\n"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			if err := tc.filter.CopySources(out, sources()...); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != tc.expected {
+				t.Fatalf("Expected: %q, got: %q", tc.expected, out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package output
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// Column is a custom column: a header and the JSONPath of its value
+type Column struct {
+	Header    string
+	FieldSpec string
+}
+
+// CustomColumnsPrinter prints a column per JSONPath expression, compatible with kubectl's -o custom-columns
+type CustomColumnsPrinter struct {
+	Columns   []Column
+	NoHeaders bool
+}
+
+// NewCustomColumnsPrinter parses a spec like 'NAME:.metadata.name,NODE:.spec.nodeName'
+func NewCustomColumnsPrinter(spec string, noHeaders bool) (*CustomColumnsPrinter, error) {
+	if len(spec) == 0 {
+		return nil, fmt.Errorf("custom-columns format specified but no custom columns given")
+	}
+	var columns []Column
+	for _, part := range strings.Split(spec, ",") {
+		header, fieldSpec, ok := strings.Cut(part, ":")
+		if !ok || header == "" {
+			return nil, fmt.Errorf("unexpected custom-columns spec: %s, expected <header>:<json-path-expr>", part)
+		}
+		expression, err := RelaxedJSONPathExpression(fieldSpec)
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, Column{Header: header, FieldSpec: expression})
+	}
+	return &CustomColumnsPrinter{Columns: columns, NoHeaders: noHeaders}, nil
+}
+
+// NewCustomColumnsPrinterFromTemplate reads a line of headers followed by a line of JSONPath expressions, separated by whitespace
+func NewCustomColumnsPrinterFromTemplate(r io.Reader, noHeaders bool) (*CustomColumnsPrinter, error) {
+	scanner := bufio.NewScanner(r)
+	var lines [][]string
+	for scanner.Scan() && len(lines) < 2 {
+		if fields := strings.Fields(scanner.Text()); len(fields) > 0 {
+			lines = append(lines, fields)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(lines) != 2 {
+		return nil, fmt.Errorf("invalid template, expected two lines: headers and JSONPath expressions")
+	}
+	if len(lines[0]) != len(lines[1]) {
+		return nil, fmt.Errorf("number of headers (%d) does not match the number of JSONPath expressions (%d)", len(lines[0]), len(lines[1]))
+	}
+	var columns []Column
+	for i, header := range lines[0] {
+		expression, err := RelaxedJSONPathExpression(lines[1][i])
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, Column{Header: header, FieldSpec: expression})
+	}
+	return &CustomColumnsPrinter{Columns: columns, NoHeaders: noHeaders}, nil
+}
+
+// PrintObj prints a row per item of a list or a single row for any other object
+func (p *CustomColumnsPrinter) PrintObj(obj runtime.Object, out io.Writer) error {
+	parsers := make([]*jsonpath.JSONPath, len(p.Columns))
+	for i, column := range p.Columns {
+		parsers[i] = jsonpath.New(fmt.Sprintf("column%d", i)).AllowMissingKeys(true)
+		if err := parsers[i].Parse(column.FieldSpec); err != nil {
+			return err
+		}
+	}
+	w := printers.GetNewTabWriter(out)
+	defer w.Flush()
+	if !p.NoHeaders {
+		headers := make([]string, len(p.Columns))
+		for i, column := range p.Columns {
+			headers[i] = column.Header
+		}
+		fmt.Fprintln(w, strings.Join(headers, "\t"))
+	}
+	items := []map[string]interface{}{}
+	switch o := obj.(type) {
+	case *unstructured.UnstructuredList:
+		for i := range o.Items {
+			items = append(items, o.Items[i].Object)
+		}
+	case runtime.Unstructured:
+		items = append(items, o.UnstructuredContent())
+	default:
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return err
+		}
+		items = append(items, content)
+	}
+	for _, item := range items {
+		if err := printRow(w, parsers, item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func printRow(w io.Writer, parsers []*jsonpath.JSONPath, item map[string]interface{}) error {
+	cells := make([]string, len(parsers))
+	for i, parser := range parsers {
+		results, err := parser.FindResults(item)
+		if err != nil {
+			return err
+		}
+		var values []string
+		for _, result := range results {
+			for _, value := range result {
+				values = append(values, fmt.Sprintf("%v", printable(value)))
+			}
+		}
+		if len(values) == 0 {
+			values = append(values, "<none>")
+		}
+		cells[i] = strings.Join(values, ",")
+	}
+	_, err := fmt.Fprintln(w, strings.Join(cells, "\t"))
+	return err
+}
+
+func printable(value reflect.Value) interface{} {
+	if !value.IsValid() || !value.CanInterface() {
+		return nil
+	}
+	return value.Interface()
+}
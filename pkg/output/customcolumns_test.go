@@ -0,0 +1,123 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func generateUnstructuredList() *unstructured.UnstructuredList {
+	return &unstructured.UnstructuredList{
+		Object: map[string]interface{}{"kind": "List", "apiVersion": "v1"},
+		Items: []unstructured.Unstructured{
+			{Object: map[string]interface{}{"kind": "Pod", "apiVersion": "v1", "metadata": map[string]interface{}{"name": "pod-a"}, "spec": map[string]interface{}{
+				"nodeName":   "master-0",
+				"containers": []interface{}{map[string]interface{}{"name": "c1"}, map[string]interface{}{"name": "c2"}},
+			}}},
+			{Object: map[string]interface{}{"kind": "Pod", "apiVersion": "v1", "metadata": map[string]interface{}{"name": "pod-b"}}},
+		},
+	}
+}
+
+func TestRelaxedJSONPathExpression(t *testing.T) {
+	tests := []struct {
+		name, in, expected string
+		expectedErr        bool
+	}{
+		{name: "plain path", in: "metadata.name", expected: "{.metadata.name}"},
+		{name: "leading dot", in: ".metadata.name", expected: "{.metadata.name}"},
+		{name: "braces", in: "{metadata.name}", expected: "{.metadata.name}"},
+		{name: "braces and leading dot", in: "{.metadata.name}", expected: "{.metadata.name}"},
+		{name: "nested braces", in: "{.metadata}{.name}", expectedErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := RelaxedJSONPathExpression(tc.in)
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("Expected error: %t, got: %v", tc.expectedErr, err)
+			}
+			if got != tc.expected {
+				t.Fatalf("Expected: %s, got: %s", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestCustomColumnsPrinter(t *testing.T) {
+	tests := []struct {
+		name, spec, template string
+		noHeaders            bool
+		expected             string
+		expectedErr          bool
+	}{
+		{
+			name:     "spec with missing values",
+			spec:     "NAME:.metadata.name,NODE:spec.nodeName,CONTAINERS:.spec.containers[*].name",
+			expected: "NAME    NODE       CONTAINERS\npod-a   master-0   c1,c2\npod-b   <none>     <none>\n",
+		},
+		{
+			name:      "spec without headers",
+			spec:      "NAME:.metadata.name",
+			noHeaders: true,
+			expected:  "pod-a\npod-b\n",
+		},
+		{
+			name:        "invalid spec",
+			spec:        "NAME",
+			expectedErr: true,
+		},
+		{
+			name:     "template",
+			template: "NAME   NODE\n.metadata.name   .spec.nodeName\n",
+			expected: "NAME    NODE\npod-a   master-0\npod-b   <none>\n",
+		},
+		{
+			name:        "template with mismatching columns",
+			template:    "NAME NODE\n.metadata.name\n",
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var printer *CustomColumnsPrinter
+			var err error
+			if tc.template != "" {
+				printer, err = NewCustomColumnsPrinterFromTemplate(strings.NewReader(tc.template), tc.noHeaders)
+			} else {
+				printer, err = NewCustomColumnsPrinter(tc.spec, tc.noHeaders)
+			}
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("Expected error: %t, got: %v", tc.expectedErr, err)
+			}
+			if err != nil {
+				return
+			}
+			var got bytes.Buffer
+			if err := printer.PrintObj(generateUnstructuredList(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if got.String() != tc.expected {
+				t.Fatalf("Expected: %q, got: %q", tc.expected, got.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package output
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// SortByName orders the items of list by namespace and name.
+// Items of the same kind are kept together, in order of the kind's first appearance.
+func SortByName(list *unstructured.UnstructuredList) {
+	rank := kindRank(list)
+	slices.SortStableFunc(list.Items, func(a, b unstructured.Unstructured) int {
+		return cmp.Or(
+			cmp.Compare(rank[a.GroupVersionKind().GroupKind()], rank[b.GroupVersionKind().GroupKind()]),
+			cmp.Compare(a.GetNamespace(), b.GetNamespace()),
+			cmp.Compare(a.GetName(), b.GetName()),
+		)
+	})
+}
+
+// SortBy orders the items of list by the value at a JSONPath expression, e.g. '.metadata.creationTimestamp'.
+// Items without a value come first, items with equal values are ordered by namespace and name.
+func SortBy(list *unstructured.UnstructuredList, expression string) error {
+	relaxed, err := RelaxedJSONPathExpression(expression)
+	if err != nil {
+		return fmt.Errorf("invalid --sort-by '%s': %w", expression, err)
+	}
+	parser := jsonpath.New("sorting").AllowMissingKeys(true)
+	if err := parser.Parse(relaxed); err != nil {
+		return fmt.Errorf("invalid --sort-by '%s': %w", expression, err)
+	}
+	SortByName(list)
+	keys := make([]interface{}, len(list.Items))
+	for i := range list.Items {
+		results, err := parser.FindResults(list.Items[i].Object)
+		if err != nil {
+			return fmt.Errorf("unable to sort by '%s': %w", expression, err)
+		}
+		if len(results) > 0 && len(results[0]) > 0 && results[0][0].CanInterface() {
+			keys[i] = results[0][0].Interface()
+		}
+	}
+	order := make([]int, len(list.Items))
+	for i := range order {
+		order[i] = i
+	}
+	rank := kindRank(list)
+	slices.SortStableFunc(order, func(i, j int) int {
+		return cmp.Or(
+			cmp.Compare(rank[list.Items[i].GroupVersionKind().GroupKind()], rank[list.Items[j].GroupVersionKind().GroupKind()]),
+			compareValues(keys[i], keys[j]),
+		)
+	})
+	sorted := make([]unstructured.Unstructured, len(list.Items))
+	for i, j := range order {
+		sorted[i] = list.Items[j]
+	}
+	list.Items = sorted
+	return nil
+}
+
+func kindRank(list *unstructured.UnstructuredList) map[schema.GroupKind]int {
+	rank := make(map[schema.GroupKind]int)
+	for _, item := range list.Items {
+		kind := item.GroupVersionKind().GroupKind()
+		if _, ok := rank[kind]; !ok {
+			rank[kind] = len(rank)
+		}
+	}
+	return rank
+}
+
+// compare values found in unstructured content: numbers numerically, quantities (e.g. 10Gi) by their value and other strings lexically
+func compareValues(a, b interface{}) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	if x, ok := number(a); ok {
+		if y, ok := number(b); ok {
+			return cmp.Compare(x, y)
+		}
+	}
+	if x, ok := a.(string); ok {
+		if y, ok := b.(string); ok {
+			qx, errX := resource.ParseQuantity(x)
+			qy, errY := resource.ParseQuantity(y)
+			if errX == nil && errY == nil {
+				return qx.Cmp(qy)
+			}
+			return cmp.Compare(x, y)
+		}
+	}
+	if x, ok := a.(bool); ok {
+		if y, ok := b.(bool); ok && x != y {
+			if x {
+				return 1
+			}
+			return -1
+		}
+	}
+	return cmp.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
@@ -0,0 +1,103 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package output
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func generateSortableList() *unstructured.UnstructuredList {
+	item := func(kind, namespace, name string, extra map[string]interface{}) unstructured.Unstructured {
+		obj := map[string]interface{}{"apiVersion": "v1", "kind": kind, "metadata": map[string]interface{}{"name": name, "namespace": namespace}}
+		for k, v := range extra {
+			obj[k] = v
+		}
+		return unstructured.Unstructured{Object: obj}
+	}
+	return &unstructured.UnstructuredList{Items: []unstructured.Unstructured{
+		item("Pod", "ns2", "b", map[string]interface{}{"status": map[string]interface{}{"restarts": int64(10)}, "capacity": "10Gi"}),
+		item("ConfigMap", "ns1", "z", nil),
+		item("Pod", "ns1", "c", map[string]interface{}{"status": map[string]interface{}{"restarts": int64(2)}, "capacity": "500Mi"}),
+		item("Pod", "ns1", "a", map[string]interface{}{"status": map[string]interface{}{"restarts": int64(10)}, "capacity": "2Gi"}),
+		item("ConfigMap", "ns1", "y", nil),
+		item("Pod", "ns1", "d", nil),
+	}}
+}
+
+func sortedNames(list *unstructured.UnstructuredList) []string {
+	var result []string
+	for _, item := range list.Items {
+		result = append(result, item.GetKind()+"/"+item.GetNamespace()+"/"+item.GetName())
+	}
+	return result
+}
+
+func TestSortByName(t *testing.T) {
+	list := generateSortableList()
+	SortByName(list)
+	expected := []string{"Pod/ns1/a", "Pod/ns1/c", "Pod/ns1/d", "Pod/ns2/b", "ConfigMap/ns1/y", "ConfigMap/ns1/z"}
+	if got := sortedNames(list); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected: %v, got: %v", expected, got)
+	}
+}
+
+func TestSortBy(t *testing.T) {
+	tests := []struct {
+		name, expression string
+		expected         []string
+		expectedErr      bool
+	}{
+		{
+			name:       "numeric values with missing values first and ties by name",
+			expression: ".status.restarts",
+			expected:   []string{"Pod/ns1/d", "Pod/ns1/c", "Pod/ns1/a", "Pod/ns2/b", "ConfigMap/ns1/y", "ConfigMap/ns1/z"},
+		},
+		{
+			name:       "quantities",
+			expression: "{.capacity}",
+			expected:   []string{"Pod/ns1/d", "Pod/ns1/c", "Pod/ns1/a", "Pod/ns2/b", "ConfigMap/ns1/y", "ConfigMap/ns1/z"},
+		},
+		{
+			name:       "string values",
+			expression: "metadata.name",
+			expected:   []string{"Pod/ns1/a", "Pod/ns2/b", "Pod/ns1/c", "Pod/ns1/d", "ConfigMap/ns1/y", "ConfigMap/ns1/z"},
+		},
+		{
+			name:        "invalid expression",
+			expression:  "{.metadata}{.name}",
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			list := generateSortableList()
+			err := SortBy(list, tc.expression)
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("Expected error: %t, got: %v", tc.expectedErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if got := sortedNames(list); !reflect.DeepEqual(got, tc.expected) {
+				t.Fatalf("Expected: %v, got: %v", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package reader
+
+import (
+	"io/fs"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/bverschueren/in2un/pkg/deserializer"
+	"github.com/bverschueren/in2un/pkg/helpers"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// APIResource describes a resource type found in an insights archive
+type APIResource struct {
+	// Name of the resource type as accepted by ReadResource, e.g. pods
+	Name       string `json:"name"`
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespaced bool   `json:"namespaced"`
+	// Count of distinct objects of this type in the archive
+	Count int `json:"count"`
+	// Sources are the top level directories of the archive holding these objects, e.g. config or conditional
+	Sources []string `json:"sources"`
+	// ShortNames are the aliases in2un accepts for the resource type, e.g. po for pods
+	ShortNames []string `json:"shortNames,omitempty"`
+}
+
+// GroupVersionKind of the resource type, empty when neither the archive nor in2un knows it
+func (r APIResource) GroupVersionKind() schema.GroupVersionKind {
+	return schema.FromAPIVersionAndKind(r.APIVersion, r.Kind)
+}
+
+// ReadAPIResources lists the resource types of the archive with the number of objects of each type, ordered by name.
+// The group, version and kind come from the types known to in2un or, for other types, from the first object found.
+func (ir *InsightsReader) ReadAPIResources() ([]APIResource, Warnings, error) {
+	return readAPIResources(ir.Index, ir.FS)
+}
+
+func readAPIResources(idx *Index, fsys fs.FS) ([]APIResource, Warnings, error) {
+	resources := make(map[string]*APIResource)
+	objects := make(map[string]map[string]bool)
+	var warnings Warnings
+	for _, entry := range idx.Entries {
+		if entry.Class != ClassResource && entry.Class != ClassConfigMap {
+			continue
+		}
+		gvk, known := deserializer.KnownType(entry.ResourceType)
+		name := apiResourceName(entry.ResourceType)
+		if known {
+			// named after the kind, e.g. clusterversions for config/version.json
+			name = helpers.ResourceForKind(gvk.Kind)
+		}
+		resource, ok := resources[name]
+		if !ok {
+			resource = &APIResource{Name: name}
+			if known {
+				resource.APIVersion, resource.Kind = gvk.GroupVersion().String(), gvk.Kind
+			}
+			resources[name] = resource
+			objects[name] = make(map[string]bool)
+		}
+		source, _, _ := strings.Cut(entry.Name, "/")
+		if !slices.Contains(resource.Sources, source) {
+			resource.Sources = append(resource.Sources, source)
+		}
+		resource.Namespaced = resource.Namespaced || entry.Namespace != ""
+		// only objects which get can read are counted, the others are reported as warnings as get does
+		if entry.Class == ClassResource {
+			raw, err := fs.ReadFile(fsys, entry.Name)
+			if err != nil {
+				return nil, nil, err
+			}
+			objectGVK, err := objectGroupVersionKind(entry.Name, raw)
+			if err != nil {
+				log.Debug(&ParseError{Path: entry.Name, Err: err})
+				warnings = append(warnings, Warning{Path: entry.Name, Err: err})
+				continue
+			}
+			if resource.Kind == "" && objectGVK.Kind != "" {
+				resource.APIVersion, resource.Kind = objectGVK.GroupVersion().String(), objectGVK.Kind
+			}
+		}
+		objects[name][entry.Namespace+"/"+entry.ResourceName] = true
+	}
+	var result []APIResource
+	for name, resource := range resources {
+		resource.Count = len(objects[name])
+		resource.ShortNames = shortNames(resource.GroupVersionKind())
+		result = append(result, *resource)
+	}
+	events, eventWarnings, err := readEvents(idx, fsys, AllNamespaceValue)
+	if err != nil {
+		return nil, nil, err
+	}
+	warnings = append(warnings, eventWarnings...)
+	if len(events.Items) > 0 {
+		result = append(result, APIResource{Name: "events", APIVersion: "v1", Kind: "Event", Namespaced: true, Count: len(events.Items), Sources: []string{"events"}})
+	}
+	slices.SortFunc(result, func(a, b APIResource) int { return strings.Compare(a.Name, b.Name) })
+	return result, warnings, nil
+}
+
+// the aliases of the resource type of a kind, e.g. po for pods
+func shortNames(gvk schema.GroupVersionKind) []string {
+	var names []string
+	for alias, resourceType := range helpers.Aliases {
+		if known, ok := deserializer.KnownType(resourceType); ok && known == gvk {
+			names = append(names, alias)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// the plural name of a resource type as named in the archive (e.g. pod or pods), unless reading
+// resources by the plural would no longer find them (e.g. config/ingress.json)
+func apiResourceName(resourceType string) string {
+	plural := resourceType
+	switch {
+	case strings.HasSuffix(resourceType, "s") && !strings.HasSuffix(resourceType, "ss"):
+	case strings.HasSuffix(resourceType, "s"):
+		plural = resourceType + "es"
+	default:
+		plural = resourceType + "s"
+	}
+	if matched, _ := regexp.MatchString(`^`+helpers.Plural(plural)+`$`, resourceType); !matched {
+		return resourceType
+	}
+	return plural
+}
+
+// the type of the object in an archive file, or of the items when it holds a list. The kind is empty when the file lacks it.
+func objectGroupVersionKind(path string, raw []byte) (schema.GroupVersionKind, error) {
+	object, err := deserializer.NewInsightsDeserializer().JsonToUnstructedFromPath(path, raw)
+	if err != nil {
+		return schema.GroupVersionKind{}, err
+	}
+	if object.GetAPIVersion() == deserializer.MissingTypeMetaFieldValue {
+		return schema.GroupVersionKind{}, nil
+	}
+	return schema.FromAPIVersionAndKind(object.GetAPIVersion(), strings.TrimSuffix(object.GetKind(), "List")), nil
+}
@@ -0,0 +1,167 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package reader
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// bump whenever the layout of Index or the classification of its entries changes so stale cache files are ignored
+const indexCacheVersion = 4
+
+const indexCacheDir = "index"
+
+// archivesFile keeps the checksums of the archives read, so the index of an unchanged archive is found without hashing it again
+const archivesFile = "archives.json"
+
+type indexCache struct {
+	Version  int    `json:"version"`
+	Checksum string `json:"checksum"`
+	Index    *Index `json:"index"`
+}
+
+// checksum returns the hex encoded sha256 of a file's content
+func checksum(filename string) (string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// archiveStamp identifies the content of an archive by its checksum as long as its size and modification time are unchanged
+type archiveStamp struct {
+	Size     int64     `json:"size"`
+	ModTime  time.Time `json:"modTime"`
+	Checksum string    `json:"checksum"`
+}
+
+// cachedChecksum returns the checksum of an archive, hashing it only when it is new or changed since it was last hashed
+func cachedChecksum(dir, filename string) (string, error) {
+	filename, err := filepath.Abs(filename)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(filename)
+	if err != nil {
+		return "", err
+	}
+	stamps := loadStamps(dir)
+	if stamp, ok := stamps[filename]; ok && stamp.Size == info.Size() && stamp.ModTime.Equal(info.ModTime()) {
+		log.Debugf("Using checksum %s of %s\n", stamp.Checksum, filename)
+		return stamp.Checksum, nil
+	}
+	sum, err := checksum(filename)
+	if err != nil {
+		return "", err
+	}
+	stamps[filename] = archiveStamp{Size: info.Size(), ModTime: info.ModTime(), Checksum: sum}
+	if err := storeStamps(dir, stamps); err != nil {
+		log.Warnf("Unable to cache checksum: %s", err)
+	}
+	return sum, nil
+}
+
+// the stamps of the archives read, keyed by their absolute path. A missing or unreadable file only costs hashing again.
+func loadStamps(dir string) map[string]archiveStamp {
+	stamps := make(map[string]archiveStamp)
+	raw, err := os.ReadFile(filepath.Join(dir, indexCacheDir, archivesFile))
+	if err == nil {
+		err = json.Unmarshal(raw, &stamps)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		log.Debugf("Ignoring archive checksums: %s\n", err)
+		return make(map[string]archiveStamp)
+	}
+	return stamps
+}
+
+func storeStamps(dir string, stamps map[string]archiveStamp) error {
+	return replaceFile(filepath.Join(dir, indexCacheDir, archivesFile), func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(stamps)
+	})
+}
+
+func indexCachePath(dir, sum string) string {
+	return filepath.Join(dir, indexCacheDir, sum+".json.gz")
+}
+
+func loadIndex(dir, sum string) (*Index, error) {
+	file, err := os.Open(indexCachePath(dir, sum))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	zr, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	var cache indexCache
+	if err := json.NewDecoder(zr).Decode(&cache); err != nil {
+		return nil, err
+	}
+	if cache.Version != indexCacheVersion || cache.Checksum != sum || cache.Index == nil {
+		return nil, fmt.Errorf("stale index cache for %s", sum)
+	}
+	log.Debugf("Loaded index from %s\n", indexCachePath(dir, sum))
+	return cache.Index, nil
+}
+
+func storeIndex(dir, sum string, idx *Index) error {
+	log.Debugf("Writing index to %s\n", indexCachePath(dir, sum))
+	return replaceFile(indexCachePath(dir, sum), func(w io.Writer) error {
+		zw := gzip.NewWriter(w)
+		if err := json.NewEncoder(zw).Encode(indexCache{Version: indexCacheVersion, Checksum: sum, Index: idx}); err != nil {
+			return err
+		}
+		return zw.Close()
+	})
+}
+
+// write a file through a temporary file in the same directory, so concurrent readers never see a partial file
+func replaceFile(name string, write func(w io.Writer) error) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0750); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
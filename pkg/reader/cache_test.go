@@ -0,0 +1,134 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package reader
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// write a gzipped archive large enough to hold several checkpoints
+func generateGzippedArchive(t *testing.T, dir string) (string, []tarrable) {
+	rnd := rand.New(rand.NewSource(1))
+	var files []tarrable
+	for i := 0; i < 12; i++ {
+		body := make([]byte, 1<<20)
+		rnd.Read(body)
+		files = append(files, tarrable{
+			Name: fmt.Sprintf("config/pod/namespace/logs/pod-%d/container_current.log", i),
+			Body: body,
+		})
+	}
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	zw.Write(generateBufferedTar(files).Bytes())
+	zw.Close()
+	archive := filepath.Join(dir, "archive.tar.gz")
+	if err := os.WriteFile(archive, compressed.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return archive, files
+}
+
+func TestIndexCache(t *testing.T) {
+	dir := t.TempDir()
+	archive, files := generateGzippedArchive(t, dir)
+	cacheDir := filepath.Join(dir, "cache")
+
+	ir, err := NewInsightsReader(archive, WithIndexCache(cacheDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ir.Index.Checkpoints) == 0 {
+		t.Fatalf("Expected checkpoints to be recorded")
+	}
+	sum, err := checksum(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cached, err := loadIndex(cacheDir, sum)
+	if err != nil {
+		t.Fatalf("Expected a cached index, got err='%s'", err)
+	}
+	if !reflect.DeepEqual(cached, ir.Index) {
+		t.Fatalf("Expected cached index to equal the built index")
+	}
+
+	// a new reader on the same archive resolves entries from the cached checkpoints
+	ir, err = NewInsightsReader(archive, WithIndexCache(cacheDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := len(files) - 1; i >= 0; i-- {
+		got := readLogString(t, ir, fmt.Sprintf("pod-%d", i), "namespace", "container")
+		if got != string(files[i].Body) {
+			t.Fatalf("Expected content of %s to match", files[i].Name)
+		}
+	}
+}
+
+func TestStaleIndexCache(t *testing.T) {
+	dir := t.TempDir()
+	if err := storeIndex(dir, "abc", &Index{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadIndex(dir, "def"); err == nil {
+		t.Fatalf("Expected an error for a missing index")
+	}
+	if _, err := loadIndex(dir, "abc"); err != nil {
+		t.Fatalf("Expected cached index, got err='%s'", err)
+	}
+}
+
+func TestCachedChecksum(t *testing.T) {
+	dir := t.TempDir()
+	archive, _ := generateGzippedArchive(t, dir)
+	sum, err := checksum(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := cachedChecksum(dir, archive); err != nil || got != sum {
+		t.Fatalf("Expected: %s, got: %s (err='%v')", sum, got, err)
+	}
+
+	// an unchanged archive is not hashed again, so a tampered stamp is returned as is
+	stamps := loadStamps(dir)
+	stamp := stamps[archive]
+	stamp.Checksum = "abc"
+	stamps[archive] = stamp
+	if err := storeStamps(dir, stamps); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := cachedChecksum(dir, archive); got != "abc" {
+		t.Fatalf("Expected: abc, got: %s", got)
+	}
+
+	// a modified archive is hashed again
+	modTime := stamp.ModTime.Add(time.Minute)
+	if err := os.Chtimes(archive, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := cachedChecksum(dir, archive); got != sum {
+		t.Fatalf("Expected: %s, got: %s", sum, got)
+	}
+}
@@ -0,0 +1,97 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package reader
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	ErrInvalidInsightsArchive = fmt.Errorf("no valid insights archive provided")
+	// the archive could not be decompressed or its tar structure is broken
+	ErrCorruptArchive = fmt.Errorf("corrupt insights archive")
+	// the archive ends before the content of an entry is complete
+	ErrTruncatedEntry = fmt.Errorf("truncated archive entry")
+)
+
+// EntryError reports a failure to read an entry of the archive
+type EntryError struct {
+	Path string
+	Err  error
+}
+
+func (e *EntryError) Error() string {
+	return fmt.Sprintf("unable to read '%s' from insights archive: %s", e.Path, e.Err)
+}
+
+func (e *EntryError) Unwrap() error {
+	return e.Err
+}
+
+// ParseError reports an entry of the archive which content could not be deserialized
+type ParseError struct {
+	Path string
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("unable to parse '%s' from insights archive: %s", e.Path, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// wrap errors from reading the (decompressed) archive stream into ErrTruncatedEntry or ErrCorruptArchive,
+// errors from accessing the file itself are returned as is
+func archiveError(err error) error {
+	var pathErr *fs.PathError
+	switch {
+	case errors.As(err, &pathErr), errors.Is(err, ErrCorruptArchive), errors.Is(err, ErrTruncatedEntry):
+		return err
+	case errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, io.EOF):
+		return fmt.Errorf("%w: %w", ErrTruncatedEntry, err)
+	default:
+		return fmt.Errorf("%w: %w", ErrCorruptArchive, err)
+	}
+}
+
+// ConversionError reports an object of the archive which does not fit the type it is read into
+type ConversionError struct {
+	// Path of the archive file holding the object
+	Path            string
+	Namespace, Name string
+	// From is the type of the object in the archive, To the type it is read into
+	From, To schema.GroupVersionKind
+	Err      error
+}
+
+func (e *ConversionError) Error() string {
+	name := e.Name
+	if e.Namespace != "" {
+		name = e.Namespace + "/" + name
+	}
+	return fmt.Sprintf("unable to read %s '%s' from insights archive as %s %s: %s", e.From.Kind, name, e.To.GroupVersion(), e.To.Kind, e.Err)
+}
+
+func (e *ConversionError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,86 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package reader
+
+import (
+	"fmt"
+	"io/fs"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/json"
+)
+
+// events are stored per namespace as a list of compacted events, e.g.
+// {"items":[{"namespace":"openshift-etcd","lastTimestamp":"2024-10-16T12:00:00Z","reason":"BackOff","message":"...","type":"Warning"}]}
+type compactedEventList struct {
+	Items []map[string]interface{} `json:"items"`
+}
+
+// ReadEvents returns the events of a namespace, or of all namespaces, as v1 Events
+func (ir *InsightsReader) ReadEvents(namespace string) (*unstructured.UnstructuredList, Warnings, error) {
+	return readEvents(ir.Index, ir.FS, namespace)
+}
+
+func readEvents(idx *Index, fsys fs.FS, namespace string) (*unstructured.UnstructuredList, Warnings, error) {
+	result := &unstructured.UnstructuredList{Object: map[string]interface{}{"kind": "List", "apiVersion": "v1"}}
+	var warnings Warnings
+	for _, entry := range idx.Entries {
+		if entry.Class != ClassEvents || (namespace != "" && namespace != AllNamespaceValue && entry.Namespace != namespace) {
+			continue
+		}
+		raw, err := fs.ReadFile(fsys, entry.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+		var events compactedEventList
+		// decode numbers as int64 like unstructured does
+		if err := json.Unmarshal(raw, &events); err != nil {
+			log.Debug(&ParseError{Path: entry.Name, Err: err})
+			warnings = append(warnings, Warning{Path: entry.Name, Err: err})
+			continue
+		}
+		for i, item := range events.Items {
+			result.Items = append(result.Items, eventFromCompacted(item, entry.Namespace, i))
+		}
+	}
+	return result, warnings, nil
+}
+
+// convert a compacted event into a v1 Event, keeping full events as they are
+func eventFromCompacted(item map[string]interface{}, namespace string, i int) unstructured.Unstructured {
+	event := unstructured.Unstructured{Object: map[string]interface{}{}}
+	for key, value := range item {
+		if key != "namespace" {
+			event.Object[key] = value
+		}
+	}
+	event.SetAPIVersion("v1")
+	event.SetKind("Event")
+	if ns, ok := item["namespace"].(string); ok && ns != "" {
+		namespace = ns
+	}
+	if event.GetNamespace() == "" {
+		event.SetNamespace(namespace)
+	}
+	if event.GetName() == "" {
+		event.SetName(fmt.Sprintf("%s.%d", namespace, i))
+	}
+	if _, found := event.Object["count"]; !found {
+		event.Object["count"] = int64(1)
+	}
+	return event
+}
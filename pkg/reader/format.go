@@ -0,0 +1,202 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package reader
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strconv"
+
+	"github.com/klauspost/compress/zstd"
+	log "github.com/sirupsen/logrus"
+)
+
+// Format is the container format of an insights archive as detected from its content
+type Format string
+
+const (
+	FormatGzip    Format = "gzip compressed tar"
+	FormatZstd    Format = "zstd compressed tar"
+	FormatTar     Format = "tar"
+	FormatZip     Format = "zip"
+	FormatUnknown Format = "unknown"
+)
+
+// UnsupportedFormatError reports a file which is not a supported insights archive container.
+// It wraps ErrInvalidInsightsArchive.
+type UnsupportedFormatError struct {
+	Path string
+	// Detected describes the content found, e.g. "bzip2 compressed data"
+	Detected string
+}
+
+func (e *UnsupportedFormatError) Error() string {
+	return fmt.Sprintf("%s: %s is not a supported insights archive, detected %s (supported: gzip or zstd compressed tar, tar, zip or an extracted directory)", ErrInvalidInsightsArchive, e.Path, e.Detected)
+}
+
+func (e *UnsupportedFormatError) Unwrap() error {
+	return ErrInvalidInsightsArchive
+}
+
+// NotTarError reports a compressed file which does not hold a tar archive.
+// It wraps ErrInvalidInsightsArchive.
+type NotTarError struct {
+	Path   string
+	Format Format
+	Err    error
+}
+
+func (e *NotTarError) Error() string {
+	return fmt.Sprintf("%s: %s is %s data but does not contain a tar archive: %s", ErrInvalidInsightsArchive, e.Path, e.Format, e.Err)
+}
+
+func (e *NotTarError) Unwrap() []error {
+	return []error{ErrInvalidInsightsArchive, e.Err}
+}
+
+var signatures = []struct {
+	offset   int
+	magic    []byte
+	detected string
+}{
+	{0, []byte{0x1f, 0x8b}, string(FormatGzip)},
+	{0, []byte{0x28, 0xb5, 0x2f, 0xfd}, string(FormatZstd)},
+	{0, []byte("PK\x03\x04"), string(FormatZip)},
+	{0, []byte("PK\x05\x06"), string(FormatZip)},
+	{257, []byte("ustar"), string(FormatTar)},
+	{0, []byte("BZh"), "bzip2 compressed data"},
+	{0, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}, "xz compressed data"},
+	{0, []byte("7z\xbc\xaf\x27\x1c"), "7-zip archive"},
+	{0, []byte("Rar!\x1a\x07"), "rar archive"},
+	{0, []byte("%PDF"), "PDF document"},
+	{0, []byte("{"), "JSON data"},
+}
+
+// detect the container format of an insights archive from its magic bytes
+func sniff(filename string) (Format, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return FormatUnknown, fmt.Errorf("unable to open insights archive: %w", err)
+	}
+	defer file.Close()
+	header := make([]byte, 512)
+	n, err := io.ReadFull(file, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return FormatUnknown, fmt.Errorf("unable to read insights archive: %w", err)
+	}
+	header = header[:n]
+	if n == 0 {
+		return FormatUnknown, &UnsupportedFormatError{Path: filename, Detected: "an empty file"}
+	}
+	for _, sig := range signatures {
+		if len(header) >= sig.offset+len(sig.magic) && bytes.Equal(header[sig.offset:sig.offset+len(sig.magic)], sig.magic) {
+			log.Debugf("Detected %s content in %s\n", sig.detected, filename)
+			switch Format(sig.detected) {
+			case FormatGzip, FormatZstd, FormatZip, FormatTar:
+				return Format(sig.detected), nil
+			}
+			return FormatUnknown, &UnsupportedFormatError{Path: filename, Detected: sig.detected}
+		}
+	}
+	// pre-POSIX tar archives lack the ustar magic, so verify the header checksum instead
+	if n == len(header) && validTarChecksum(header) {
+		log.Debugf("Detected %s content in %s\n", FormatTar, filename)
+		return FormatTar, nil
+	}
+	return FormatUnknown, &UnsupportedFormatError{Path: filename, Detected: "unrecognized content"}
+}
+
+// the checksum of a tar header is the sum of all its bytes with the checksum field itself taken as spaces
+func validTarChecksum(header []byte) bool {
+	stored, err := strconv.ParseInt(string(bytes.Trim(header[148:156], " \x00")), 8, 64)
+	if err != nil {
+		return false
+	}
+	var sum int64
+	for i, b := range header {
+		if i >= 148 && i < 156 {
+			b = ' '
+		}
+		sum += int64(b)
+	}
+	return sum == stored
+}
+
+// resumeOffset returns the offset openTarAt starts reading the uncompressed tar stream from to get to offset:
+// offset itself for a plain tar, the closest checkpoint before it for gzip and the start of the stream for zstd
+func resumeOffset(format Format, checkpoints []Checkpoint, offset int64) int64 {
+	switch format {
+	case FormatTar:
+		return offset
+	case FormatGzip:
+		if i := checkpointBefore(checkpoints, offset); i >= 0 {
+			return checkpoints[i].Offset
+		}
+	}
+	return 0
+}
+
+// openTarAt returns the uncompressed tar stream positioned at offset
+func openTarAt(filename string, format Format, checkpoints []Checkpoint, offset int64) (io.ReadCloser, error) {
+	if format == FormatGzip {
+		return openGzipAt(filename, checkpoints, offset)
+	}
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case FormatTar:
+		if _, err := file.Seek(offset, io.SeekStart); err != nil {
+			file.Close()
+			return nil, err
+		}
+		return file, nil
+	case FormatZstd:
+		zr, err := zstd.NewReader(file)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		if _, err := io.CopyN(io.Discard, zr, offset); err != nil {
+			zr.Close()
+			file.Close()
+			return nil, err
+		}
+		return &readCloser{Reader: zr, close: func() error { zr.Close(); return file.Close() }}, nil
+	}
+	file.Close()
+	return nil, &UnsupportedFormatError{Path: filename, Detected: string(format)}
+}
+
+// extracted archives and zip files may hold the archive content in a single top-level directory, e.g. when re-packed
+func archiveRoot(fsys fs.FS) (fs.FS, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) != 1 || !entries[0].IsDir() || entries[0].Name() == "config" {
+		return fsys, nil
+	}
+	if _, err := fs.Stat(fsys, entries[0].Name()+"/config"); err != nil {
+		return fsys, nil
+	}
+	log.Debugf("Using top-level directory '%s' as archive root\n", entries[0].Name())
+	return fs.Sub(fsys, entries[0].Name())
+}
@@ -0,0 +1,187 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package reader
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+var formatFiles = []tarrable{
+	{Name: "config/clusteroperator/network.json", Body: []byte(`{"metadata":{},"kind":"FakeKind","apiVersion":"Fake1.2"}`)},
+	{Name: "config/pod/openshift-multus/logs/multus-sns4n/kube-multus_current.log", Body: []byte("log line")},
+}
+
+func generateArchive(t *testing.T, format Format, prefix string) string {
+	var buf bytes.Buffer
+	switch format {
+	case FormatTar:
+		buf = *generateBufferedTar(formatFiles)
+	case FormatGzip:
+		zw := gzip.NewWriter(&buf)
+		zw.Write(generateBufferedTar(formatFiles).Bytes())
+		zw.Close()
+	case FormatZstd:
+		zw, _ := zstd.NewWriter(&buf)
+		zw.Write(generateBufferedTar(formatFiles).Bytes())
+		zw.Close()
+	case FormatZip:
+		zw := zip.NewWriter(&buf)
+		for _, file := range formatFiles {
+			w, _ := zw.Create(prefix + file.Name)
+			w.Write(file.Body)
+		}
+		zw.Close()
+	}
+	name := filepath.Join(t.TempDir(), "archive")
+	if err := os.WriteFile(name, buf.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func TestSupportedFormats(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		prefix string
+	}{
+		{name: "read plain tar", format: FormatTar},
+		{name: "read gzip compressed tar", format: FormatGzip},
+		{name: "read zstd compressed tar", format: FormatZstd},
+		{name: "read zip", format: FormatZip},
+		{name: "read zip with top-level directory", format: FormatZip, prefix: "insights-archive/"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ir, err := NewInsightsReader(generateArchive(t, tc.format, tc.prefix), WithIndexCache(t.TempDir()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer ir.Close()
+			if ir.Format != tc.format {
+				t.Fatalf("Expected format %s, got %s", tc.format, ir.Format)
+			}
+			if got := mustReadResource(t, ir, "clusteroperator", "network", "", "", ""); len(got.Items) != 1 {
+				t.Fatalf("Expected 1 clusteroperator, got %d", len(got.Items))
+			}
+			logs := readLogString(t, ir, "multus-sns4n", "openshift-multus", "kube-multus")
+			if string(logs) != "log line" {
+				t.Fatalf("Expected log 'log line', got '%s'", logs)
+			}
+		})
+	}
+}
+
+func TestUnsupportedFormats(t *testing.T) {
+	var gzippedText bytes.Buffer
+	zw := gzip.NewWriter(&gzippedText)
+	zw.Write([]byte("not a tar archive"))
+	zw.Close()
+
+	tests := []struct {
+		name        string
+		content     []byte
+		expectedErr func(error) bool
+	}{
+		{
+			name:        "return UnsupportedFormatError for empty file",
+			content:     []byte{},
+			expectedErr: isUnsupportedFormatError,
+		},
+		{
+			name:        "return UnsupportedFormatError for bzip2 file",
+			content:     []byte("BZh91AY&SY"),
+			expectedErr: isUnsupportedFormatError,
+		},
+		{
+			name:        "return UnsupportedFormatError for unknown content",
+			content:     bytes.Repeat([]byte("plain text "), 100),
+			expectedErr: isUnsupportedFormatError,
+		},
+		{
+			name:        "return NotTarError for gzip compressed text",
+			content:     gzippedText.Bytes(),
+			expectedErr: isNotTarError,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			name := filepath.Join(t.TempDir(), "archive")
+			if err := os.WriteFile(name, tc.content, 0600); err != nil {
+				t.Fatal(err)
+			}
+			_, err := NewInsightsReader(name)
+			if !errors.Is(err, ErrInvalidInsightsArchive) {
+				t.Fatalf("Expected err='%s', got err='%s'", ErrInvalidInsightsArchive, err)
+			}
+			if !tc.expectedErr(err) {
+				t.Fatalf("Expected a different error type, got err='%s'", err)
+			}
+		})
+	}
+}
+
+func isUnsupportedFormatError(err error) bool {
+	var target *UnsupportedFormatError
+	return errors.As(err, &target)
+}
+
+func isNotTarError(err error) bool {
+	var target *NotTarError
+	return errors.As(err, &target)
+}
+
+func TestCorruptGzip(t *testing.T) {
+	var buf bytes.Buffer
+	// stored blocks keep the deflate stream valid when flipping a payload byte, so only the checksum catches it
+	zw, _ := gzip.NewWriterLevel(&buf, gzip.NoCompression)
+	zw.Write(generateBufferedTar(formatFiles).Bytes())
+	zw.Close()
+	tests := []struct {
+		name   string
+		offset int
+	}{
+		{name: "payload", offset: 10 + 5 + 520},
+		{name: "checksum", offset: buf.Len() - 8},
+		{name: "size", offset: buf.Len() - 1},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			content := bytes.Clone(buf.Bytes())
+			content[tc.offset] ^= 0x01
+			name := filepath.Join(t.TempDir(), "archive")
+			if err := os.WriteFile(name, content, 0600); err != nil {
+				t.Fatal(err)
+			}
+			cache := t.TempDir()
+			_, err := NewInsightsReader(name, WithIndexCache(cache))
+			if !errors.Is(err, ErrCorruptArchive) {
+				t.Fatalf("Expected err='%s', got err='%v'", ErrCorruptArchive, err)
+			}
+			if files, _ := filepath.Glob(filepath.Join(cache, indexCacheDir, "*.json.gz")); len(files) != 0 {
+				t.Fatalf("Expected: no cached index, got: %v", files)
+			}
+		})
+	}
+}
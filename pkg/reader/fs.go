@@ -0,0 +1,111 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package reader
+
+import (
+	"bytes"
+	"io/fs"
+	"path"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// tarFS serves the files of a tar archive using its index
+type tarFS struct {
+	idx    *Index
+	byName map[string]int
+	// keep the stream open between reads so reading entries in archive order only decompresses once
+	mu sync.Mutex
+	sr *sequentialReader
+}
+
+func newTarFS(idx *Index, open opener, resume func(offset int64) int64) *tarFS {
+	byName := make(map[string]int, len(idx.Entries))
+	for i, entry := range idx.Entries {
+		byName[entry.Name] = i
+	}
+	return &tarFS{idx: idx, byName: byName, sr: newSequentialReader(open, resume)}
+}
+
+func (t *tarFS) Open(name string) (fs.File, error) {
+	content, err := t.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return &memFile{Reader: bytes.NewReader(content), name: path.Base(name), size: int64(len(content))}, nil
+}
+
+func (t *tarFS) ReadFile(name string) ([]byte, error) {
+	i, ok := t.byName[name]
+	if !ok || !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.sr.ReadEntry(t.idx.Entries[i])
+}
+
+func (t *tarFS) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.sr.Close()
+}
+
+// memFile is a file which content is already read from the archive
+type memFile struct {
+	*bytes.Reader
+	name string
+	size int64
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) { return f, nil }
+func (f *memFile) Close() error               { return nil }
+func (f *memFile) Name() string               { return f.name }
+func (f *memFile) Size() int64                { return f.size }
+func (f *memFile) Mode() fs.FileMode          { return 0444 }
+func (f *memFile) ModTime() time.Time         { return time.Time{} }
+func (f *memFile) IsDir() bool                { return false }
+func (f *memFile) Sys() any                   { return nil }
+
+// build an index of all regular files in a file system, e.g. an extracted insights archive
+func indexFS(fsys fs.FS) (*Index, error) {
+	idx := &Index{}
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		entry := IndexEntry{Name: name, Size: info.Size()}
+		entry.Class, entry.ResourceType, entry.Namespace, entry.ResourceName = classify(name)
+		idx.observeModTime(info.ModTime())
+		log.Tracef("indexed '%s' as %s", entry.Name, entry.Class)
+		idx.Entries = append(idx.Entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Debugf("Indexed %d entries\n", len(idx.Entries))
+	return idx, nil
+}
@@ -0,0 +1,272 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package reader
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/bverschueren/in2un/pkg/reader/internal/flate"
+	log "github.com/sirupsen/logrus"
+)
+
+// uncompressed distance between gzip checkpoints, each checkpoint costs up to 32KiB of window data
+const checkpointSpan = 4 << 20
+
+const (
+	gzipID1     = 0x1f
+	gzipID2     = 0x8b
+	gzipDeflate = 8
+	flagHdrCrc  = 1 << 1
+	flagExtra   = 1 << 2
+	flagName    = 1 << 3
+	flagComment = 1 << 4
+)
+
+// Checkpoint allows resuming decompression of a gzip compressed archive
+// without decompressing it from the start
+type Checkpoint struct {
+	// Offset in the uncompressed tar stream
+	Offset int64
+	// In is the offset in the compressed file of the byte the deflate block starts in
+	In int64
+	// Bits of the byte at In belonging to the previous deflate block
+	Bits uint8
+	// Window is the uncompressed data preceding Offset
+	Window []byte
+}
+
+// byteCounter keeps track of the offset in the compressed file
+type byteCounter struct {
+	*bufio.Reader
+	n int64
+}
+
+func (b *byteCounter) Read(p []byte) (int, error) {
+	n, err := b.Reader.Read(p)
+	b.n += int64(n)
+	return n, err
+}
+
+func (b *byteCounter) ReadByte() (byte, error) {
+	c, err := b.Reader.ReadByte()
+	if err == nil {
+		b.n++
+	}
+	return c, err
+}
+
+func (b *byteCounter) ReadBytes(delim byte) ([]byte, error) {
+	line, err := b.Reader.ReadBytes(delim)
+	b.n += int64(len(line))
+	return line, err
+}
+
+func (b *byteCounter) Discard(n int) (int, error) {
+	discarded, err := b.Reader.Discard(n)
+	b.n += int64(discarded)
+	return discarded, err
+}
+
+// gzipReader decompresses all members of a gzip file, optionally recording checkpoints along the way
+type gzipReader struct {
+	br *byteCounter
+	fr io.ReadCloser
+	// uncompressed offset
+	out int64
+	// nil unless checkpoints should be recorded
+	checkpoints *[]Checkpoint
+	// CRC32 and size of the current member's uncompressed data, nil when not read from the start of the member
+	digest hash.Hash32
+	size   uint32
+	// io.EOF once the last member is read or the error which stopped decompression, returned on every later read
+	err error
+}
+
+// newGzipReader reads a gzip file from its start and records checkpoints when requested
+func newGzipReader(r io.Reader, checkpoints *[]Checkpoint) (*gzipReader, error) {
+	g := &gzipReader{
+		br:          &byteCounter{Reader: bufio.NewReader(r)},
+		checkpoints: checkpoints,
+	}
+	if err := g.nextMember(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// resumeGzipReader continues decompression from a checkpoint, r must be positioned at c.In
+func resumeGzipReader(r io.Reader, c Checkpoint) (*gzipReader, error) {
+	g := &gzipReader{
+		br:  &byteCounter{Reader: bufio.NewReader(r), n: c.In},
+		out: c.Offset,
+	}
+	fr, err := flate.NewReaderAt(g.br, flate.Checkpoint{Bits: c.Bits, Window: c.Window})
+	if err != nil {
+		return nil, err
+	}
+	g.fr = fr
+	return g, nil
+}
+
+func (g *gzipReader) Read(p []byte) (int, error) {
+	if g.err != nil {
+		return 0, g.err
+	}
+	n, err := g.read(p)
+	if err != nil {
+		g.err = err
+	}
+	return n, err
+}
+
+func (g *gzipReader) read(p []byte) (int, error) {
+	for {
+		n, err := g.fr.Read(p)
+		g.out += int64(n)
+		if g.digest != nil {
+			g.digest.Write(p[:n])
+			g.size += uint32(n)
+		}
+		if err != io.EOF {
+			return n, err
+		}
+		// check the CRC32 and ISIZE trailer and continue with the next member, if any
+		if err := g.checkTrailer(); err != nil {
+			return n, err
+		}
+		if _, err := g.br.Peek(1); err == io.EOF {
+			return n, io.EOF
+		}
+		if err := g.nextMember(); err != nil {
+			return n, err
+		}
+		if n > 0 {
+			return n, nil
+		}
+	}
+}
+
+// compare the trailer of a member with the data read, as compress/gzip does.
+// A member resumed from a checkpoint is not read from its start, so only its trailer's presence is checked.
+func (g *gzipReader) checkTrailer() error {
+	trailer := make([]byte, 8)
+	if _, err := io.ReadFull(g.br, trailer); err != nil {
+		return io.ErrUnexpectedEOF
+	}
+	if g.digest == nil {
+		return nil
+	}
+	if binary.LittleEndian.Uint32(trailer[:4]) != g.digest.Sum32() || binary.LittleEndian.Uint32(trailer[4:]) != g.size {
+		return fmt.Errorf("%w: gzip checksum mismatch", ErrCorruptArchive)
+	}
+	return nil
+}
+
+// parse a gzip member header and start decompressing its deflate stream
+func (g *gzipReader) nextMember() error {
+	if err := skipGzipHeader(g.br); err != nil {
+		return err
+	}
+	g.digest, g.size = crc32.NewIEEE(), 0
+	if g.checkpoints == nil {
+		g.fr = flate.NewReader(g.br)
+		return nil
+	}
+	base, out := g.br.n, g.out
+	g.fr = flate.NewCheckpointReader(g.br, checkpointSpan, func(c flate.Checkpoint) {
+		log.Tracef("recording gzip checkpoint at offset %d", out+c.Out)
+		*g.checkpoints = append(*g.checkpoints, Checkpoint{
+			Offset: out + c.Out,
+			In:     base + c.In,
+			Bits:   c.Bits,
+			Window: c.Window,
+		})
+	})
+	return nil
+}
+
+// skip a gzip member header as described in RFC 1952
+func skipGzipHeader(br *byteCounter) error {
+	hdr := make([]byte, 10)
+	if _, err := io.ReadFull(br, hdr); err != nil {
+		return ErrInvalidInsightsArchive
+	}
+	if hdr[0] != gzipID1 || hdr[1] != gzipID2 || hdr[2] != gzipDeflate {
+		return ErrInvalidInsightsArchive
+	}
+	flags := hdr[3]
+	if flags&flagExtra != 0 {
+		size := make([]byte, 2)
+		if _, err := io.ReadFull(br, size); err != nil {
+			return ErrInvalidInsightsArchive
+		}
+		if _, err := br.Discard(int(binary.LittleEndian.Uint16(size))); err != nil {
+			return ErrInvalidInsightsArchive
+		}
+	}
+	for _, flag := range []byte{flagName, flagComment} {
+		if flags&flag != 0 {
+			if _, err := br.ReadBytes(0); err != nil {
+				return ErrInvalidInsightsArchive
+			}
+		}
+	}
+	if flags&flagHdrCrc != 0 {
+		if _, err := br.Discard(2); err != nil {
+			return ErrInvalidInsightsArchive
+		}
+	}
+	return nil
+}
+
+// the index of the last checkpoint at or before offset, -1 when there is none
+func checkpointBefore(checkpoints []Checkpoint, offset int64) int {
+	return sort.Search(len(checkpoints), func(i int) bool { return checkpoints[i].Offset > offset }) - 1
+}
+
+// openGzipAt returns the uncompressed stream positioned at offset, resuming from the closest checkpoint
+func openGzipAt(filename string, checkpoints []Checkpoint, offset int64) (io.ReadCloser, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	var g *gzipReader
+	i := checkpointBefore(checkpoints, offset)
+	if i < 0 {
+		g, err = newGzipReader(file, nil)
+	} else {
+		log.Tracef("resuming from gzip checkpoint at offset %d for offset %d", checkpoints[i].Offset, offset)
+		if _, err = file.Seek(checkpoints[i].In, io.SeekStart); err == nil {
+			g, err = resumeGzipReader(file, checkpoints[i])
+		}
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if _, err := io.CopyN(io.Discard, g, offset-g.out); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &readCloser{Reader: g, close: file.Close}, nil
+}
@@ -0,0 +1,248 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package reader
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// EntryClass describes what kind of data an archive entry holds
+type EntryClass int
+
+const (
+	ClassUnknown EntryClass = iota
+	// a json serialized resource (e.g. config/pod/<namespace>/<name>.json)
+	ClassResource
+	// a single key of a ConfigMap stored as plain file (config/configmaps/<namespace>/<name>/<key>)
+	ClassConfigMap
+	// a container log file (config/pod/<namespace>/logs/<pod>/<container>_current.log),
+	// or one of the conditional gatherer (conditional/namespaces/<namespace>/pods/<pod>/containers/<container>/logs/last-N-lines.log)
+	ClassLog
+	// the compacted events of a namespace (events/<namespace>.json)
+	ClassEvents
+)
+
+func (c EntryClass) String() string {
+	switch c {
+	case ClassResource:
+		return "resource"
+	case ClassConfigMap:
+		return "configmap"
+	case ClassLog:
+		return "log"
+	case ClassEvents:
+		return "events"
+	default:
+		return "unknown"
+	}
+}
+
+// IndexEntry describes a single file in an insights archive
+type IndexEntry struct {
+	// Name is the path of the file inside the archive
+	Name string `json:"name"`
+	// Offset is the position of the file content in the uncompressed tar stream
+	Offset int64 `json:"offset"`
+	// Size is the length of the file content
+	Size int64 `json:"size"`
+
+	Class        EntryClass `json:"class"`
+	ResourceType string     `json:"resourceType,omitempty"`
+	Namespace    string     `json:"namespace,omitempty"`
+	ResourceName string     `json:"resourceName,omitempty"`
+}
+
+// Index holds all regular files of an insights archive in archive order,
+// so lookups can be resolved without rescanning the archive
+type Index struct {
+	Entries []IndexEntry `json:"entries"`
+	// Checkpoints to resume decompression from, ordered by offset
+	Checkpoints []Checkpoint `json:"checkpoints,omitempty"`
+	// GatherTime is the modification time of the most recent file, when the insights operator wrote the archive
+	GatherTime time.Time `json:"gatherTime"`
+}
+
+// keep the most recent modification time as gather time, archives written without modification times leave it unknown
+func (idx *Index) observeModTime(t time.Time) {
+	if t.Unix() > 0 && t.After(idx.GatherTime) {
+		idx.GatherTime = t.UTC()
+	}
+}
+
+// build an index from an uncompressed tar stream in a single pass
+func buildIndex(r io.Reader) (*Index, error) {
+	cr := &countingReader{r: r}
+	tr := tar.NewReader(cr)
+	idx := &Index{}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break // end of archive
+		}
+		if err != nil {
+			// failing on the very first header means this is no tar archive at all
+			if len(idx.Entries) == 0 && (errors.Is(err, tar.ErrHeader) || errors.Is(err, io.ErrUnexpectedEOF)) {
+				return nil, errNotTar{err}
+			}
+			return nil, fmt.Errorf("unable to index insights archive: %w", archiveError(err))
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		entry := IndexEntry{
+			// archives packed from within their directory hold names like ./config/...
+			Name: strings.TrimPrefix(hdr.Name, "./"),
+			// tar.Reader does not buffer, so after reading the header we are at the start of the content
+			Offset: cr.n,
+			Size:   hdr.Size,
+		}
+		entry.Class, entry.ResourceType, entry.Namespace, entry.ResourceName = classify(entry.Name)
+		idx.observeModTime(hdr.ModTime)
+		log.Tracef("indexed '%s' as %s at offset %d", entry.Name, entry.Class, entry.Offset)
+		idx.Entries = append(idx.Entries, entry)
+	}
+	// read up to the end of the stream, so the decompressor checks the checksum of the whole archive
+	if _, err := io.Copy(io.Discard, cr); err != nil {
+		return nil, fmt.Errorf("unable to index insights archive: %w", archiveError(err))
+	}
+	log.Debugf("Indexed %d entries\n", len(idx.Entries))
+	return idx, nil
+}
+
+type errNotTar struct {
+	err error
+}
+
+func (e errNotTar) Error() string { return e.err.Error() }
+
+// derive the resource type, namespace and name from the location of a file in the archive
+func classify(name string) (class EntryClass, resourceType, namespace, resourceName string) {
+	parts := strings.Split(strings.Trim(name, "/"), "/")
+	ext := path.Ext(name)
+	stem := strings.TrimSuffix(path.Base(name), ext)
+	switch parts[0] {
+	case "config":
+		// storage resources are nested one level deeper (config/storage/storageclasses/<name>.json)
+		if len(parts) > 2 && parts[1] == "storage" {
+			parts = append([]string{parts[0]}, parts[2:]...)
+		}
+		switch {
+		case len(parts) == 5 && parts[1] == "configmaps":
+			return ClassConfigMap, parts[1], parts[2], parts[3]
+		case len(parts) == 6 && parts[3] == "logs" && ext == ".log":
+			return ClassLog, parts[1], parts[2], parts[4]
+		case len(parts) == 2 && ext == ".json":
+			return ClassResource, stem, "", ""
+		case len(parts) == 3 && ext == ".json":
+			return ClassResource, parts[1], "", stem
+		case len(parts) == 4 && ext == ".json":
+			return ClassResource, parts[1], parts[2], stem
+		}
+	case "conditional":
+		switch {
+		case len(parts) == 5 && parts[1] == "namespaces" && ext == ".json":
+			return ClassResource, parts[3], parts[2], stem
+		case len(parts) == 9 && parts[1] == "namespaces" && parts[3] == "pods" && parts[5] == "containers" && ext == ".log":
+			return ClassLog, "pod", parts[2], parts[4]
+		}
+	case "events":
+		if len(parts) == 2 && ext == ".json" {
+			return ClassEvents, "events", stem, ""
+		}
+	}
+	return ClassUnknown, "", "", ""
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// sequentialReader reads entry contents from a forward pass over the uncompressed tar stream.
+// Requesting entries in increasing offset order, which is the order of Index.Entries, avoids decompressing twice.
+// The stream is reopened when going back, or when skipping a large part of the archive and reopening
+// resumes past the current position (e.g. from a gzip checkpoint).
+type sequentialReader struct {
+	open opener
+	// resume returns the offset reopening the stream at offset starts decompressing from
+	resume func(offset int64) int64
+	rc     io.ReadCloser
+	pos    int64
+}
+
+// newSequentialReader reads from the streams returned by open, resume is nil when they can only be read from the start
+func newSequentialReader(open opener, resume func(offset int64) int64) *sequentialReader {
+	if resume == nil {
+		resume = func(int64) int64 { return 0 }
+	}
+	return &sequentialReader{open: open, resume: resume}
+}
+
+// whether reopening the stream at offset is cheaper than reading on from the current position
+func (s *sequentialReader) reopen(offset int64) bool {
+	if s.rc == nil || offset < s.pos {
+		return true
+	}
+	return offset-s.pos > checkpointSpan && s.resume(offset) > s.pos
+}
+
+func (s *sequentialReader) ReadEntry(entry IndexEntry) ([]byte, error) {
+	// only open the archive when content is needed
+	if s.reopen(entry.Offset) {
+		if err := s.Close(); err != nil {
+			return nil, err
+		}
+		rc, err := s.open(entry.Offset)
+		if err != nil {
+			return nil, &EntryError{Path: entry.Name, Err: archiveError(err)}
+		}
+		s.rc, s.pos = rc, entry.Offset
+	}
+	if _, err := io.CopyN(io.Discard, s.rc, entry.Offset-s.pos); err != nil {
+		s.Close()
+		return nil, &EntryError{Path: entry.Name, Err: archiveError(err)}
+	}
+	content := make([]byte, entry.Size)
+	if _, err := io.ReadFull(s.rc, content); err != nil {
+		s.Close()
+		return nil, &EntryError{Path: entry.Name, Err: archiveError(err)}
+	}
+	s.pos = entry.Offset + entry.Size
+	return content, nil
+}
+
+func (s *sequentialReader) Close() error {
+	if s.rc == nil {
+		return nil
+	}
+	rc := s.rc
+	s.rc = nil
+	return rc.Close()
+}
@@ -0,0 +1,105 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package flate
+
+import (
+	"io"
+)
+
+const (
+	// from compress/flate's deflate.go and huffman_bit_writer.go
+	maxMatchOffset = 1 << 15
+	endBlockMarker = 256
+)
+
+// Checkpoint marks the start of a block in a DEFLATE stream. Decompression can
+// be resumed from a checkpoint since the state of the decompressor at a block
+// boundary is fully determined by its input position and the preceding window.
+type Checkpoint struct {
+	// In is the offset of the byte holding the first bit of the block,
+	// relative to the start of the DEFLATE stream.
+	In int64
+	// Bits is the number of low bits of that byte belonging to the previous block.
+	Bits uint8
+	// Out is the offset in the uncompressed stream.
+	Out int64
+	// Window holds up to 32KiB of uncompressed data preceding Out.
+	Window []byte
+}
+
+type checkpointReader struct {
+	f    *decompressor
+	out  int64
+	last int64
+}
+
+func (c *checkpointReader) Read(b []byte) (int, error) {
+	n, err := c.f.Read(b)
+	c.out += int64(n)
+	return n, err
+}
+
+func (c *checkpointReader) Close() error {
+	return c.f.Close()
+}
+
+// NewCheckpointReader is like NewReader but calls fn at the start of blocks
+// which are at least span uncompressed bytes apart.
+func NewCheckpointReader(r io.Reader, span int64, fn func(Checkpoint)) io.ReadCloser {
+	c := &checkpointReader{f: NewReader(r).(*decompressor)}
+	c.f.onBlock = func(f *decompressor) {
+		// the decompressor only continues with the next block when all
+		// previous output is either returned or still pending in the window
+		out := c.out + int64(f.dict.availRead())
+		if out == 0 || out-c.last < span {
+			return
+		}
+		c.last = out
+		bit := f.roffset*8 - int64(f.nb)
+		fn(Checkpoint{
+			In:     bit / 8,
+			Bits:   uint8(bit % 8),
+			Out:    out,
+			Window: f.dict.window(),
+		})
+	}
+	return c
+}
+
+// NewReaderAt resumes decompression at a checkpoint. The reader r must be
+// positioned at the byte at offset c.In of the DEFLATE stream.
+func NewReaderAt(r io.Reader, c Checkpoint) (io.ReadCloser, error) {
+	f := NewReaderDict(r, c.Window).(*decompressor)
+	if c.Bits != 0 {
+		b, err := f.r.ReadByte()
+		if err != nil {
+			return nil, noEOF(err)
+		}
+		f.roffset++
+		f.b = uint32(b) >> c.Bits
+		f.nb = 8 - uint(c.Bits)
+	}
+	return f, nil
+}
+
+// window returns a copy of the history in output order.
+func (dd *dictDecoder) window() []byte {
+	window := make([]byte, 0, dd.histSize())
+	if dd.full {
+		window = append(window, dd.hist[dd.wrPos:]...)
+	}
+	return append(window, dd.hist[:dd.wrPos]...)
+}
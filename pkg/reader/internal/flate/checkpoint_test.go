@@ -0,0 +1,71 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package flate
+
+import (
+	"bytes"
+	stdflate "compress/flate"
+	"fmt"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+func TestResumeFromCheckpoints(t *testing.T) {
+	// mix compressible and random data to get both huffman and stored blocks
+	rnd := rand.New(rand.NewSource(1))
+	var plain bytes.Buffer
+	for i := 0; plain.Len() < 4<<20; i++ {
+		if i%3 == 0 {
+			chunk := make([]byte, 50000)
+			rnd.Read(chunk)
+			plain.Write(chunk)
+		} else {
+			fmt.Fprintf(&plain, "line %d of a rather repetitive log file\n", i)
+		}
+	}
+	var compressed bytes.Buffer
+	w, _ := stdflate.NewWriter(&compressed, stdflate.DefaultCompression)
+	w.Write(plain.Bytes())
+	w.Close()
+
+	var checkpoints []Checkpoint
+	got, err := io.ReadAll(NewCheckpointReader(bytes.NewReader(compressed.Bytes()), 256<<10, func(c Checkpoint) {
+		checkpoints = append(checkpoints, c)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, plain.Bytes()) {
+		t.Fatalf("Expected checkpoint reader output to equal the input")
+	}
+	if len(checkpoints) < 2 {
+		t.Fatalf("Expected multiple checkpoints, got %d", len(checkpoints))
+	}
+	for _, c := range checkpoints {
+		r, err := NewReaderAt(bytes.NewReader(compressed.Bytes()[c.In:]), c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resumed, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("Unable to resume at %+v: %s", c.Out, err)
+		}
+		if !bytes.Equal(resumed, plain.Bytes()[c.Out:]) {
+			t.Fatalf("Expected resumed output at %d to equal the input", c.Out)
+		}
+	}
+}
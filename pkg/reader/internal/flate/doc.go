@@ -0,0 +1,16 @@
+// Copyright 2009 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package flate is a copy of the decompressor of the standard library's
+// compress/flate package, extended to report block boundaries so
+// decompression can later be resumed from a checkpoint.
+//
+// inflate.go and dict_decoder.go are taken unchanged from src/compress/flate
+// of Go go1.27.1, except for this package comment replacing upstream's and
+// the onBlock hook of the decompressor, which is marked with in2un: comments
+// in inflate.go. All other additions (Checkpoint, NewCheckpointReader,
+// NewReaderAt and dictDecoder.window) live in checkpoint.go, so rebasing on a
+// newer Go release means copying both files again and re-adding the onBlock
+// field and its call in nextBlock.
+package flate
@@ -0,0 +1,102 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package reader
+
+import (
+	"bytes"
+	"cmp"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+)
+
+// LogSource tells which part of an insights archive a log was gathered in
+type LogSource string
+
+const (
+	// pod logs gathered with the cluster resources (config/pod/<namespace>/logs/...)
+	LogSourceConfig LogSource = "config"
+	// container logs collected by the conditional gatherer on alerts (conditional/namespaces/<namespace>/pods/...)
+	LogSourceConditional LogSource = "conditional"
+)
+
+var logSourceOrder = map[LogSource]int{LogSourceConfig: 0, LogSourceConditional: 1}
+
+// ContainerLog is the log file of a single container in an insights archive
+type ContainerLog struct {
+	Namespace string
+	Pod       string
+	Container string
+	// Previous is set for the log of the previous instance of the container
+	Previous bool
+	// Path of the log file in the archive
+	Path   string
+	Source LogSource
+}
+
+// ListLogs returns the logs of the containers of a pod, those of all containers when containerName is empty.
+// Pod logs come first in archive order, followed by the logs of the conditional gatherer.
+func (ir *InsightsReader) ListLogs(podName, namespace, containerName string, previous bool) []ContainerLog {
+	return listLogs(ir.Index, podName, namespace, containerName, previous)
+}
+
+// ReadContainerLog returns the content of a container log listed by ListLogs
+func (ir *InsightsReader) ReadContainerLog(l ContainerLog) (io.Reader, error) {
+	raw, err := fs.ReadFile(ir.FS, l.Path)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(raw), nil
+}
+
+func listLogs(idx *Index, podName, namespace, containerName string, previous bool) []ContainerLog {
+	var result []ContainerLog
+	for _, entry := range idx.Entries {
+		if entry.Class != ClassLog || entry.ResourceName != podName || (namespace != "" && namespace != AllNamespaceValue && entry.Namespace != namespace) {
+			continue
+		}
+		container, isPrevious, source := containerLogFromPath(entry.Name)
+		if (containerName != "" && container != containerName) || isPrevious != previous {
+			continue
+		}
+		result = append(result, ContainerLog{
+			Namespace: entry.Namespace,
+			Pod:       entry.ResourceName,
+			Container: container,
+			Previous:  previous,
+			Path:      entry.Name,
+			Source:    source,
+		})
+	}
+	slices.SortStableFunc(result, func(a, b ContainerLog) int {
+		return cmp.Compare(logSourceOrder[a.Source], logSourceOrder[b.Source])
+	})
+	return result
+}
+
+// derive the container of a log file and whether it is the log of its previous instance, e.g.
+// config/pod/<namespace>/logs/<pod>/<container>_previous.log or
+// conditional/namespaces/<namespace>/pods/<pod>/containers/<container>/logs-previous/last-100-lines.log
+func containerLogFromPath(name string) (container string, previous bool, source LogSource) {
+	parts := strings.Split(name, "/")
+	if parts[0] == "conditional" && len(parts) > 2 {
+		return parts[len(parts)-3], parts[len(parts)-2] == "logs-previous", LogSourceConditional
+	}
+	container, version := containerAndVersionFromFilename(path.Base(name))
+	return container, version == "previous", LogSourceConfig
+}
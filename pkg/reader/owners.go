@@ -0,0 +1,102 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package reader
+
+import (
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// kinds owning pods, by resource type
+var podOwnerKinds = map[string]string{
+	"deployment":  "Deployment",
+	"replicaset":  "ReplicaSet",
+	"daemonset":   "DaemonSet",
+	"statefulset": "StatefulSet",
+}
+
+// ReadOwnedPods returns the pods of a deployment, replicaset, daemonset or statefulset.
+// Pods are selected with the selector of their owner when the owner is part of the archive,
+// otherwise with their owner references.
+func (ir *InsightsReader) ReadOwnedPods(resourceGroup, resourceName, namespace string) (*unstructured.UnstructuredList, Warnings, error) {
+	kind, ok := podOwnerKinds[strings.TrimSuffix(resourceGroup, "s")]
+	if !ok {
+		return nil, nil, fmt.Errorf("unable to find pods for resource type '%s', supported types are deployments, replicasets, daemonsets and statefulsets", resourceGroup)
+	}
+	pods, warnings, err := ir.ReadResource("pod", "", namespace, "", "")
+	if err != nil {
+		return nil, nil, err
+	}
+	owners, ownerWarnings, err := ir.ReadResource(resourceGroup, resourceName, namespace, "", "")
+	if err != nil {
+		return nil, nil, err
+	}
+	warnings = append(warnings, ownerWarnings...)
+	// owners of the same name may exist in several namespaces when reading all namespaces
+	selectors := make(map[string]labels.Selector)
+	for i := range owners.Items {
+		selector, err := podSelector(&owners.Items[i])
+		if err != nil {
+			return nil, nil, err
+		}
+		selectors[owners.Items[i].GetNamespace()] = selector
+	}
+	result := &unstructured.UnstructuredList{Object: pods.Object}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		matched := ownedBy(pod, kind, resourceName)
+		if len(selectors) > 0 {
+			selector, ok := selectors[pod.GetNamespace()]
+			matched = ok && selector.Matches(labels.Set(pod.GetLabels()))
+		}
+		if matched {
+			result.Items = append(result.Items, *pod)
+		}
+	}
+	return result, warnings, nil
+}
+
+// the selector of the pods of an owner, e.g. spec.selector of a deployment
+func podSelector(owner *unstructured.Unstructured) (labels.Selector, error) {
+	raw, found, err := unstructured.NestedMap(owner.Object, "spec", "selector")
+	if err != nil || !found {
+		return nil, fmt.Errorf("%s '%s' has no pod selector", owner.GetKind(), owner.GetName())
+	}
+	var selector metav1.LabelSelector
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(raw, &selector); err != nil {
+		return nil, fmt.Errorf("invalid pod selector of %s '%s': %w", owner.GetKind(), owner.GetName(), err)
+	}
+	return metav1.LabelSelectorAsSelector(&selector)
+}
+
+// whether a pod is owned by the given kind and name. Pods of a deployment are owned by one of its
+// replicasets, named after the deployment and the hash of the pod template.
+func ownedBy(pod *unstructured.Unstructured, kind, name string) bool {
+	for _, ref := range pod.GetOwnerReferences() {
+		switch {
+		case ref.Kind == kind && ref.Name == name:
+			return true
+		case kind == "Deployment" && ref.Kind == "ReplicaSet" && ref.Name == name+"-"+pod.GetLabels()["pod-template-hash"]:
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,307 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package reader
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strings"
+
+	"github.com/bverschueren/in2un/pkg/deserializer"
+	"github.com/bverschueren/in2un/pkg/filter"
+	"github.com/bverschueren/in2un/pkg/helpers"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Query selects resources of a single type from the archive.
+// The type is given by exactly one of ResourceType, Resource or Kind, all other fields narrow down the result.
+type Query struct {
+	// ResourceType as named in the archive or by kubectl, singular, plural or aliased (e.g. pod, pods or co)
+	ResourceType string
+	// Resource selects the type by group and plural resource name (e.g. config.openshift.io/v1, Resource=clusteroperators), the version is ignored
+	Resource schema.GroupVersionResource
+	// Kind selects the type by group and kind (e.g. v1, Kind=Pod), the version is ignored
+	Kind schema.GroupVersionKind
+	// Names of the resources, all resources when empty
+	Names []string
+	// Namespaces to read namespaced resources from, all namespaces when empty
+	Namespaces []string
+	// LabelSelector and FieldSelector filter resources as kubectl does, e.g. app=etcd or status.phase!=Running
+	LabelSelector, FieldSelector string
+	// Limit is the maximum number of resources returned, unlimited when 0
+	Limit int
+	// IncludeConditional adds the resources collected by the conditional gatherer (conditional/namespaces/...)
+	IncludeConditional bool
+	// OverrideAPIVersion and OverrideKind set the type of resources lacking it in the archive
+	OverrideAPIVersion, OverrideKind string
+}
+
+type QueryOption func(*Query)
+
+// WithResourceType selects resources by their type as named in the archive or by kubectl, e.g. pods or co
+func WithResourceType(resourceType string) QueryOption {
+	return func(q *Query) {
+		q.ResourceType = resourceType
+	}
+}
+
+// WithResource selects resources by group and plural resource name
+func WithResource(gvr schema.GroupVersionResource) QueryOption {
+	return func(q *Query) {
+		q.Resource = gvr
+	}
+}
+
+// WithKind selects resources by group and kind
+func WithKind(gvk schema.GroupVersionKind) QueryOption {
+	return func(q *Query) {
+		q.Kind = gvk
+	}
+}
+
+func WithNames(names ...string) QueryOption {
+	return func(q *Query) {
+		q.Names = append(q.Names, names...)
+	}
+}
+
+func WithNamespaces(namespaces ...string) QueryOption {
+	return func(q *Query) {
+		q.Namespaces = append(q.Namespaces, namespaces...)
+	}
+}
+
+func WithLabelSelector(selector string) QueryOption {
+	return func(q *Query) {
+		q.LabelSelector = selector
+	}
+}
+
+func WithFieldSelector(selector string) QueryOption {
+	return func(q *Query) {
+		q.FieldSelector = selector
+	}
+}
+
+func WithLimit(limit int) QueryOption {
+	return func(q *Query) {
+		q.Limit = limit
+	}
+}
+
+// WithConditional includes the resources collected by the conditional gatherer
+func WithConditional() QueryOption {
+	return func(q *Query) {
+		q.IncludeConditional = true
+	}
+}
+
+// WithTypeOverride sets the apiVersion and kind of resources lacking them in the archive
+func WithTypeOverride(apiVersion, kind string) QueryOption {
+	return func(q *Query) {
+		q.OverrideAPIVersion, q.OverrideKind = apiVersion, kind
+	}
+}
+
+// Query returns the resources selected by the options, e.g.
+//
+//	ir.Query(WithKind(corev1.SchemeGroupVersion.WithKind("Pod")), WithNamespaces("openshift-etcd"), WithLabelSelector("app=etcd"))
+func (ir *InsightsReader) Query(o ...QueryOption) (*unstructured.UnstructuredList, Warnings, error) {
+	q := Query{}
+	for _, opt := range o {
+		opt(&q)
+	}
+	return ir.RunQuery(q)
+}
+
+// RunQuery returns the resources selected by q in archive order, together with warnings for matching entries which could not be parsed
+func (ir *InsightsReader) RunQuery(q Query) (*unstructured.UnstructuredList, Warnings, error) {
+	return runQuery(ir.Index, ir.FS, q)
+}
+
+func (q Query) validate() error {
+	selected := 0
+	for _, set := range []bool{q.ResourceType != "", !q.Resource.Empty(), !q.Kind.Empty()} {
+		if set {
+			selected++
+		}
+	}
+	switch {
+	case selected == 0:
+		return errors.New("invalid query: a resource type, resource or kind is required")
+	case selected > 1:
+		return errors.New("invalid query: only one of resource type, resource or kind can be given")
+	case q.Limit < 0:
+		return fmt.Errorf("invalid query: negative limit %d", q.Limit)
+	}
+	return nil
+}
+
+func runQuery(idx *Index, fsys fs.FS, q Query) (*unstructured.UnstructuredList, Warnings, error) {
+	return runQueries(idx, fsys, []Query{q})
+}
+
+// resolve all queries in a single pass over the archive and return their resources in the order of the queries,
+// resources selected by several queries are returned once
+func runQueries(idx *Index, fsys fs.FS, queries []Query) (*unstructured.UnstructuredList, Warnings, error) {
+	groups := make([]matchGroup, len(queries))
+	// files are named after the resource they hold, except for events and well-known files holding a list,
+	// whose objects are filtered by name once read
+	byName := make([]bool, len(queries))
+	for i, q := range queries {
+		if err := q.validate(); err != nil {
+			return nil, nil, err
+		}
+		if q.selectsEvents() {
+			byName[i] = true
+			continue
+		}
+		matches := q.matchEntries(idx)
+		byName[i] = len(matches) == 1 && matches[0].stop
+		apiVersion, kind := q.OverrideAPIVersion, q.OverrideKind
+		// the kind asked for is the best guess for resources lacking their type
+		if apiVersion == "" && kind == "" && !q.Kind.Empty() {
+			apiVersion, kind = q.Kind.GroupVersion().String(), q.Kind.Kind
+		}
+		groups[i] = matchGroup{matches: matches, apiVersion: apiVersion, kind: kind}
+	}
+	found, warnings, err := readMatches(idx, fsys, groups)
+	if err != nil {
+		return nil, nil, err
+	}
+	result := &unstructured.UnstructuredList{Object: map[string]interface{}{"kind": "List", "apiVersion": "v1"}}
+	for i, q := range queries {
+		list := &unstructured.UnstructuredList{Object: result.Object, Items: found[i]}
+		if q.selectsEvents() {
+			var eventWarnings Warnings
+			if list, eventWarnings, err = readEvents(idx, fsys, AllNamespaceValue); err != nil {
+				return nil, nil, err
+			}
+			warnings = append(warnings, eventWarnings...)
+		}
+		list = q.filter(list, byName[i])
+		if list, err = filter.ByLabels(list, q.LabelSelector); err != nil {
+			return nil, nil, err
+		}
+		if list, err = filter.ByFields(list, q.FieldSelector); err != nil {
+			return nil, nil, err
+		}
+		if q.Limit > 0 && len(list.Items) > q.Limit {
+			list.Items = list.Items[:q.Limit]
+		}
+		result.Items = append(result.Items, list.Items...)
+	}
+	return result, warnings, nil
+}
+
+// whether the query selects resources of all types, e.g. get all
+func (q Query) selectsAll() bool {
+	return q.ResourceType != "" && helpers.Unalias(q.ResourceType) == "all"
+}
+
+// events are not stored as resources but as compacted lists per namespace
+func (q Query) selectsEvents() bool {
+	switch {
+	case q.ResourceType != "":
+		return apiResourceName(q.ResourceType) == "events" || q.ResourceType == "ev"
+	case !q.Resource.Empty():
+		return q.Resource.GroupResource() == schema.GroupResource{Resource: "events"}
+	}
+	return q.Kind.GroupKind() == schema.GroupKind{Kind: "Event"}
+}
+
+// find the index entries holding resources of the query. A well-known config/<type>.json holds all resources of its type,
+// so when it matches the type asked for, it is the only entry read.
+func (q Query) matchEntries(idx *Index) []match {
+	var matches []match
+	for i, entry := range idx.Entries {
+		if !q.matchesEntry(entry) {
+			continue
+		}
+		if wellKnownInsightsJson(entry.Name) && !q.selectsAll() {
+			return []match{{entry: i, resourceFile: entry.Name, stop: true}}
+		}
+		matches = append(matches, match{entry: i, resourceFile: entry.Name})
+	}
+	return matches
+}
+
+func (q Query) matchesEntry(entry IndexEntry) bool {
+	if entry.Class != ClassResource && entry.Class != ClassConfigMap {
+		return false
+	}
+	if strings.HasPrefix(entry.Name, "conditional/") && !q.IncludeConditional {
+		return false
+	}
+	if len(q.Namespaces) > 0 && entry.Namespace != "" && !slices.Contains(q.Namespaces, entry.Namespace) {
+		return false
+	}
+	if len(q.Names) > 0 && entry.ResourceName != "" && !slices.Contains(q.Names, entry.ResourceName) {
+		return false
+	}
+	return q.matchesType(entry.ResourceType)
+}
+
+// whether resources stored under resourceType in the archive are of the type of the query.
+// Types unknown to in2un are matched by name and checked against the type of the objects once read.
+func (q Query) matchesType(resourceType string) bool {
+	known, isKnown := deserializer.KnownType(resourceType)
+	switch {
+	case q.ResourceType != "":
+		wanted := helpers.Unalias(q.ResourceType)
+		if wanted == "all" || apiResourceName(wanted) == apiResourceName(resourceType) {
+			return true
+		}
+		// the name api-resources lists known types by, e.g. clusterversions for config/version.json
+		if isKnown && helpers.ResourceForKind(known.Kind) == apiResourceName(wanted) {
+			return true
+		}
+		gvk, ok := deserializer.KnownType(wanted)
+		return ok && isKnown && gvk == known
+	case !q.Resource.Empty():
+		if isKnown {
+			return known.Group == q.Resource.Group && helpers.ResourceForKind(known.Kind) == q.Resource.Resource
+		}
+		return apiResourceName(resourceType) == q.Resource.Resource
+	default:
+		if isKnown {
+			return known.GroupKind() == q.Kind.GroupKind()
+		}
+		return apiResourceName(resourceType) == helpers.ResourceForKind(q.Kind.Kind) || resourceType == strings.ToLower(q.Kind.Kind)
+	}
+}
+
+// keep the objects of the namespaces and type of the query, and of its names when the files read are not named after them
+func (q Query) filter(list *unstructured.UnstructuredList, byName bool) *unstructured.UnstructuredList {
+	result := &unstructured.UnstructuredList{Object: list.Object}
+	for _, item := range list.Items {
+		gvk := item.GroupVersionKind()
+		// the type of resources unknown to in2un and lacking it in the archive cannot be checked
+		dummy := item.GetAPIVersion() == deserializer.MissingTypeMetaFieldValue
+		switch {
+		case byName && len(q.Names) > 0 && !slices.Contains(q.Names, item.GetName()):
+		case len(q.Namespaces) > 0 && item.GetNamespace() != "" && !slices.Contains(q.Namespaces, item.GetNamespace()):
+		case !dummy && !q.Resource.Empty() && gvk.Group != q.Resource.Group:
+		case !dummy && !q.Kind.Empty() && gvk.GroupKind() != q.Kind.GroupKind():
+		default:
+			result.Items = append(result.Items, item)
+		}
+	}
+	return result
+}
@@ -0,0 +1,164 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package reader
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestQuery(t *testing.T) {
+	files := []tarrable{
+		{Name: "config/pod/ns1/a.json", Body: []byte(`{"metadata":{"name":"a","namespace":"ns1","labels":{"app":"web"}},"status":{"phase":"Running"}}`)},
+		{Name: "config/pod/ns1/b.json", Body: []byte(`{"metadata":{"name":"b","namespace":"ns1"},"status":{"phase":"Pending"}}`)},
+		{Name: "config/pod/ns2/c.json", Body: []byte(`{"metadata":{"name":"c","namespace":"ns2","labels":{"app":"web"}},"status":{"phase":"Running"}}`)},
+		{Name: "conditional/namespaces/ns3/pods/d.json", Body: []byte(`{"metadata":{"name":"d","namespace":"ns3"}}`)},
+		{Name: "config/clusteroperator/network.json", Body: []byte(`{"metadata":{"name":"network"}}`)},
+		{Name: "config/version.json", Body: []byte(`{"apiVersion":"config.openshift.io/v1","kind":"ClusterVersion","metadata":{"name":"version"}}`)},
+		{Name: "config/widgets/w.json", Body: []byte(`{"apiVersion":"example.com/v1","kind":"Widget","metadata":{"name":"w"}}`)},
+		{Name: "events/ns1.json", Body: []byte(`{"items":[{"namespace":"ns1","reason":"Started","message":"Started container"}]}`)},
+	}
+	ir, err := newBufferedInsightsReader(generateBufferedTar(files))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name          string
+		options       []QueryOption
+		expectedNames []string
+		expectedError bool
+	}{
+		{
+			name:          "resource type",
+			options:       []QueryOption{WithResourceType("pods")},
+			expectedNames: []string{"a", "b", "c"},
+		},
+		{
+			name:          "aliased resource type",
+			options:       []QueryOption{WithResourceType("co")},
+			expectedNames: []string{"network"},
+		},
+		{
+			name:          "short name",
+			options:       []QueryOption{WithResourceType("po"), WithNamespaces("ns2")},
+			expectedNames: []string{"c"},
+		},
+		{
+			name:          "resource",
+			options:       []QueryOption{WithResource(schema.GroupVersionResource{Group: "config.openshift.io", Version: "v1", Resource: "clusteroperators"})},
+			expectedNames: []string{"network"},
+		},
+		{
+			name:          "kind",
+			options:       []QueryOption{WithKind(schema.GroupVersionKind{Version: "v1", Kind: "Pod"})},
+			expectedNames: []string{"a", "b", "c"},
+		},
+		{
+			name:          "kind unknown to in2un",
+			options:       []QueryOption{WithKind(schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"})},
+			expectedNames: []string{"w"},
+		},
+		{
+			name:          "kind of another group",
+			options:       []QueryOption{WithKind(schema.GroupVersionKind{Group: "other.com", Version: "v1", Kind: "Widget"})},
+			expectedNames: nil,
+		},
+		{
+			name:          "well-known file",
+			options:       []QueryOption{WithResourceType("version"), WithNames("version")},
+			expectedNames: []string{"version"},
+		},
+		{
+			name:          "all resource types",
+			options:       []QueryOption{WithResourceType("all")},
+			expectedNames: []string{"a", "b", "c", "network", "version", "w"},
+		},
+		{
+			name:          "names",
+			options:       []QueryOption{WithResourceType("pod"), WithNames("a", "c")},
+			expectedNames: []string{"a", "c"},
+		},
+		{
+			name:          "namespaces",
+			options:       []QueryOption{WithResourceType("pod"), WithNamespaces("ns2", "ns3")},
+			expectedNames: []string{"c"},
+		},
+		{
+			name:          "conditional",
+			options:       []QueryOption{WithResourceType("pod"), WithNamespaces("ns2", "ns3"), WithConditional()},
+			expectedNames: []string{"c", "d"},
+		},
+		{
+			name:          "label selector",
+			options:       []QueryOption{WithResourceType("pod"), WithLabelSelector("app=web")},
+			expectedNames: []string{"a", "c"},
+		},
+		{
+			name:          "field selector",
+			options:       []QueryOption{WithResourceType("pod"), WithFieldSelector("status.phase=Running")},
+			expectedNames: []string{"a", "c"},
+		},
+		{
+			name:          "limit",
+			options:       []QueryOption{WithResourceType("pod"), WithLimit(2)},
+			expectedNames: []string{"a", "b"},
+		},
+		{
+			name:          "events",
+			options:       []QueryOption{WithKind(schema.GroupVersionKind{Version: "v1", Kind: "Event"}), WithNamespaces("ns1")},
+			expectedNames: []string{"ns1.0"},
+		},
+		{
+			name:          "no type",
+			options:       []QueryOption{WithNames("a")},
+			expectedError: true,
+		},
+		{
+			name:          "ambiguous type",
+			options:       []QueryOption{WithResourceType("pod"), WithKind(schema.GroupVersionKind{Version: "v1", Kind: "Pod"})},
+			expectedError: true,
+		},
+		{
+			name:          "invalid selector",
+			options:       []QueryOption{WithResourceType("pod"), WithLabelSelector("app in web")},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, _, err := ir.Query(tc.options...)
+			if tc.expectedError {
+				if err == nil {
+					t.Fatalf("Expected: error, got: %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, item := range got.Items {
+				names = append(names, item.GetName())
+			}
+			if !reflect.DeepEqual(names, tc.expectedNames) {
+				t.Fatalf("Expected: %v, got: %v", tc.expectedNames, names)
+			}
+		})
+	}
+}
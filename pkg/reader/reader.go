@@ -16,186 +16,362 @@ limitations under the License.
 package reader
 
 import (
-	"archive/tar"
+	"archive/zip"
 	"bytes"
-	"compress/gzip"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path"
 	"regexp"
+	"slices"
 	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
 	"github.com/bverschueren/in2un/pkg/deserializer"
+	"github.com/klauspost/compress/zstd"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
-var ErrInvalidInsightsArchive = fmt.Errorf("no valid insights (gzip compressed) archive provided")
-
 const AllNamespaceValue = "_all_"
 
 type InsightsReader struct {
-	Path   string
-	Reader *tar.Reader
+	Path  string
+	Index *Index
+	// FS gives access to the files of the archive, either from an (extracted) directory or a tar archive
+	FS fs.FS
+	// Format of the archive file, empty for an extracted archive directory
+	Format Format
+	// cache the index in this directory, keyed by the archive's checksum which is looked up by path, size and modification time
+	cacheDir string
+	closer   io.Closer
 }
 
-func NewInsightsReader(path string) (*InsightsReader, error) {
-	r, err := open(path)
+type InsightsReaderOption func(*InsightsReader)
+
+// opener returns the uncompressed tar stream of an insights archive positioned at offset
+type opener func(offset int64) (io.ReadCloser, error)
+
+func NewInsightsReader(path string, o ...InsightsReaderOption) (*InsightsReader, error) {
+	ir := &InsightsReader{Path: path}
+	for _, opt := range o {
+		opt(ir)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open insights archive: %w", err)
+	}
+	// an already extracted archive
+	if info.IsDir() {
+		return ir, ir.indexFS(os.DirFS(path))
+	}
+	ir.Format, err = sniff(path)
 	if err != nil {
 		return nil, err
-	} else {
-		return &InsightsReader{Reader: r, Path: path}, nil
 	}
+	// zip files provide random access, so no need to index their uncompressed stream
+	if ir.Format == FormatZip {
+		zr, err := zip.OpenReader(path)
+		if err != nil {
+			return nil, &UnsupportedFormatError{Path: path, Detected: fmt.Sprintf("a corrupt %s file (%s)", FormatZip, err)}
+		}
+		ir.closer = zr
+		return ir, ir.indexFS(zr)
+	}
+	idx, err := ir.index()
+	if err != nil {
+		return nil, err
+	}
+	ir.Index = idx
+	tfs := newTarFS(idx, func(offset int64) (io.ReadCloser, error) {
+		return openTarAt(path, ir.Format, idx.Checkpoints, offset)
+	}, func(offset int64) int64 {
+		return resumeOffset(ir.Format, idx.Checkpoints, offset)
+	})
+	ir.FS, ir.closer = tfs, tfs
+	return ir, nil
+}
+
+func (ir *InsightsReader) indexFS(fsys fs.FS) error {
+	root, err := archiveRoot(fsys)
+	if err != nil {
+		return fmt.Errorf("unable to open insights archive: %w", err)
+	}
+	ir.FS = root
+	ir.Index, err = indexFS(root)
+	if err != nil {
+		return fmt.Errorf("unable to index insights archive: %w", err)
+	}
+	return nil
+}
+
+// Close releases any file kept open to read from the archive
+func (ir *InsightsReader) Close() error {
+	if ir.closer == nil {
+		return nil
+	}
+	return ir.closer.Close()
+}
+
+// store the archive index in dir and reuse it on subsequent reads of the same archive
+func WithIndexCache(dir string) InsightsReaderOption {
+	return func(ir *InsightsReader) {
+		log.Debugf("caching index in %s\n", dir)
+		ir.cacheDir = dir
+	}
+}
+
+// load the index from the cache or build it by scanning the archive once
+func (ir *InsightsReader) index() (*Index, error) {
+	if ir.cacheDir == "" {
+		return indexArchive(ir.Path)
+	}
+	sum, err := cachedChecksum(ir.cacheDir, ir.Path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open insights archive: %w", err)
+	}
+	idx, err := loadIndex(ir.cacheDir, sum)
+	if err == nil {
+		return idx, nil
+	}
+	log.Debugf("No usable cached index: %s\n", err)
+	idx, err = indexArchive(ir.Path)
+	if err != nil {
+		return nil, err
+	}
+	if err := storeIndex(ir.cacheDir, sum, idx); err != nil {
+		log.Warnf("Unable to cache index: %s", err)
+	}
+	return idx, nil
+}
+
+func indexArchive(filename string) (*Index, error) {
+	var checkpoints []Checkpoint
+	rc, err := open(filename, &checkpoints)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	idx, err := buildIndex(rc)
+	if notTar, ok := err.(errNotTar); ok {
+		format, _ := sniff(filename)
+		return nil, &NotTarError{Path: filename, Format: format, Err: notTar.err}
+	}
+	if err != nil {
+		return nil, err
+	}
+	idx.Checkpoints = checkpoints
+	return idx, nil
+}
+
+// ResourceRef refers to all resources of a type or, when ResourceName is set, a single resource
+type ResourceRef struct {
+	ResourceGroup, ResourceName string
+}
+
+// ReadResource returns all resources matching the arguments, together with warnings for matching entries which could not be parsed.
+// Query selects resources with less ambiguity and more criteria.
+func (ir *InsightsReader) ReadResource(resourceGroup, resourceName, namespace, overrideApiVersion, overrideKind string) (*unstructured.UnstructuredList, Warnings, error) {
+	return ir.RunQuery(resourceQuery(ResourceRef{ResourceGroup: resourceGroup, ResourceName: resourceName}, namespace, overrideApiVersion, overrideKind))
+}
+
+// ReadResources resolves all refs in a single pass over the archive and returns the matching resources in the order of the refs
+func (ir *InsightsReader) ReadResources(refs []ResourceRef, namespace, overrideApiVersion, overrideKind string) (*unstructured.UnstructuredList, Warnings, error) {
+	queries := make([]Query, 0, len(refs))
+	for _, ref := range refs {
+		queries = append(queries, resourceQuery(ref, namespace, overrideApiVersion, overrideKind))
+	}
+	return runQueries(ir.Index, ir.FS, queries)
 }
 
-func (ir *InsightsReader) ReadResource(resourceGroup, resourceName, namespace, overrideApiVersion, overrideKind string) *unstructured.UnstructuredList {
-	configRegex := NewResourceRegex(resourceGroup, resourceName, namespace,
-		NewConfigRegex(
-			resourceGroup,
-			resourceName,
-			namespace,
-		))
-	conditionalRegex := NewResourceRegex(resourceGroup, resourceName, namespace,
-		NewConditionalRegex(
-			resourceGroup,
-			resourceName,
-			namespace,
-		),
-	)
-	operatorConfigRegex := NewOperatorConfigRegex(
-		resourceGroup,
-		resourceName,
-	)
-	return readResources(ir.Reader, []IRegex{configRegex, conditionalRegex, operatorConfigRegex}, overrideApiVersion, overrideKind)
+// the query for a ref as given on the command line, an empty namespace or AllNamespaceValue select all namespaces
+func resourceQuery(ref ResourceRef, namespace, overrideApiVersion, overrideKind string) Query {
+	q := Query{ResourceType: ref.ResourceGroup, IncludeConditional: true, OverrideAPIVersion: overrideApiVersion, OverrideKind: overrideKind}
+	if ref.ResourceName != "" {
+		q.Names = []string{ref.ResourceName}
+	}
+	if namespace != "" && namespace != AllNamespaceValue {
+		q.Namespaces = []string{namespace}
+	}
+	return q
 }
 
-func (ir *InsightsReader) ReadResourceTypes() *map[string]bool {
-	resourceListRegex := NewResourceListRegex()
-	return readResourceTypes(ir.Reader, []IRegex{resourceListRegex})
+func (ir *InsightsReader) ReadResourceTypes() (*map[string]bool, error) {
+	return readResourceTypes(ir.Index), nil
 }
 
-func (ir *InsightsReader) ReadLog(resourceGroup, resourceName, namespace, containerName string, previous bool) io.Reader {
-	return readLogs(ir.Reader, resourceGroup, resourceName, namespace, containerName, previous)
+// GatherTime returns when the archive was gathered, the zero time when unknown
+func (ir *InsightsReader) GatherTime() time.Time {
+	return ir.Index.GatherTime
+}
+
+// ReferenceTime returns the time ages are relative to: when the archive was gathered, the current time when unknown
+func (ir *InsightsReader) ReferenceTime() time.Time {
+	if t := ir.GatherTime(); !t.IsZero() {
+		return t
+	}
+	return time.Now()
 }
 
-// read plain or gzipped tar and return tar.Reader
-func open(filename string) (*tar.Reader, error) {
+func (ir *InsightsReader) ReadLog(resourceGroup, resourceName, namespace, containerName string, previous bool) (io.Reader, error) {
+	return readLogs(ir.Index, ir.FS, resourceGroup, resourceName, namespace, containerName, previous)
+}
+
+// read a plain, gzip or zstd compressed tar and return the uncompressed tar stream, recording checkpoints for gzip when requested
+func open(filename string, checkpoints *[]Checkpoint) (io.ReadCloser, error) {
+	format, err := sniff(filename)
+	if err != nil {
+		return nil, err
+	}
 	file, err := os.Open(filename)
 	if err != nil {
 		return nil, fmt.Errorf("unable to open insights archive: %w", err)
 	}
 	var reader io.Reader
-	// insights archives are gzipped so try opening as such
-	reader, err = gzip.NewReader(file)
-	if err != nil {
-		return nil, ErrInvalidInsightsArchive
-	}
-	tr := tar.NewReader(reader)
-	return tr, nil
-}
-
-// read a resource or log from an archive and return either an unstructed for resource or bytes.Buffer for logs
-func readResources(tr *tar.Reader, regs []IRegex, overrideApiVersion, overrideKind string) *unstructured.UnstructuredList {
-
-	log.Debugf("Searching tar file for regex '%s'\n", regs)
-	var result []unstructured.Unstructured
-	configMaps := deserializer.NewConfigMapData()
-	insightsDeserializer := deserializer.NewInsightsDeserializer(
-		deserializer.WithApiVersion(overrideApiVersion),
-		deserializer.WithKind(overrideKind),
-	)
-	for {
-		hdr, err := tr.Next()
-		if err == io.EOF {
-			break // end of archive
-		}
-		if err != nil {
-			log.Fatal(err)
-		}
-		for _, reg := range regs {
-			stop, resourceFile := reg.Do(hdr.Name)
-			if resourceFile != "" {
-				var raw bytes.Buffer
-				raw.ReadFrom(tr)
-
-				namespace, name, key, isConfigMap := configMapFromFilename(resourceFile)
-				if isConfigMap {
-					configMaps.Upsert(namespace, name, key, raw.String())
-				} else {
-					object, err := insightsDeserializer.JsonToUnstructed(raw.Bytes())
-					if stop {
-						result = append(result, *object)
-						return &unstructured.UnstructuredList{
-							Object: map[string]interface{}{"kind": "List", "apiVersion": "v1"},
-							Items:  result,
-						}
-					}
-					if err == nil {
-						result = append(result, *object)
-					} else {
-						log.Debug(err)
-					}
-				}
-			}
+	closer := file.Close
+	switch format {
+	case FormatGzip:
+		reader, err = newGzipReader(file, checkpoints)
+	case FormatZstd:
+		var zr *zstd.Decoder
+		if zr, err = zstd.NewReader(file); err == nil {
+			reader, closer = zr, func() error { zr.Close(); return file.Close() }
 		}
+	case FormatTar:
+		reader = file
+	default:
+		err = &UnsupportedFormatError{Path: filename, Detected: fmt.Sprintf("a %s file, which is not a tar stream", format)}
 	}
-	result = append(result, configMaps.Flatten()...)
-	return &unstructured.UnstructuredList{
-		Object: map[string]interface{}{"kind": "List", "apiVersion": "v1"},
-		Items:  result,
+	if err != nil {
+		file.Close()
+		return nil, err
 	}
+	return &readCloser{Reader: reader, close: closer}, nil
 }
 
-func readResourceTypes(tr *tar.Reader, regs []IRegex) *map[string]bool {
-	log.Debugf("Searching tar file for regex '%s'\n", regs)
-	result := make(map[string]bool)
-	for {
-		hdr, err := tr.Next()
-		if err == io.EOF {
-			break // end of archive
+type readCloser struct {
+	io.Reader
+	close func() error
+}
+
+func (r *readCloser) Close() error {
+	return r.close()
+}
+
+// a file in the archive matching a query
+type match struct {
+	entry        int
+	resourceFile string
+	// the file is a well-known json holding the only resource for the query
+	stop bool
+}
+
+// the entries matching a query, with the type to set on resources lacking it
+type matchGroup struct {
+	matches          []match
+	apiVersion, kind string
+}
+
+// read the matched entries as unstructured, grouped by query and in archive order within a group.
+// Files are read in archive order, so all groups are resolved in a single pass over the archive.
+// An entry matched by several groups is returned in the first one only, with the type override of that group.
+func readMatches(idx *Index, fsys fs.FS, groups []matchGroup) ([][]unstructured.Unstructured, Warnings, error) {
+	stops := make(map[int]bool)
+	deserializers := make(map[int]*deserializer.InsightsDeserializer)
+	for _, group := range groups {
+		d := deserializer.NewInsightsDeserializer(
+			deserializer.WithApiVersion(group.apiVersion),
+			deserializer.WithKind(group.kind),
+		)
+		for _, m := range group.matches {
+			stops[m.entry] = stops[m.entry] || m.stop
+			if _, ok := deserializers[m.entry]; !ok {
+				deserializers[m.entry] = d
+			}
 		}
+	}
+	entries := make([]int, 0, len(stops))
+	for i := range stops {
+		entries = append(entries, i)
+	}
+	slices.Sort(entries)
+
+	raws := make(map[int][]byte, len(entries))
+	objects := make(map[int]*unstructured.Unstructured, len(entries))
+	failed := make(map[int]error)
+	for _, i := range entries {
+		entry := idx.Entries[i]
+		raw, err := fs.ReadFile(fsys, entry.Name)
 		if err != nil {
-			log.Fatal(err)
+			return nil, nil, err
+		}
+		if entry.Class == ClassConfigMap {
+			raws[i] = raw
+			continue
 		}
-		for _, reg := range regs {
-			_, resourceFile := reg.Do(hdr.Name)
-			if resourceFile != "" {
-				found := resourceTypeFromResourcePath(resourceFile)
-				log.Tracef("found resourceType '%s' from file '%s'", found, resourceFile)
-				result[resourceTypeFromResourcePath(resourceFile)] = true
+		object, err := deserializers[i].JsonToUnstructedFromPath(entry.Name, raw)
+		if err != nil {
+			if stops[i] {
+				return nil, nil, &ParseError{Path: entry.Name, Err: err}
 			}
+			log.Debug(&ParseError{Path: entry.Name, Err: err})
+			failed[i] = err
+			continue
 		}
+		objects[i] = object
 	}
-	return &result
-}
 
-func readLogs(tr *tar.Reader, resourceGroup, resourceName, namespace, containerName string, previous bool) io.ReadCloser {
-	regex := NewLogRegex(resourceGroup, resourceName, namespace, containerName, previous)
-	regs := []IRegex{regex}
-	log.Debugf("Searching tar file for regex '%s'\n", regs)
-	for {
-		hdr, err := tr.Next()
-		if err == io.EOF {
-			break // end of archive
+	result := make([][]unstructured.Unstructured, len(groups))
+	var warnings Warnings
+	seen := make(map[int]bool)
+	for g, group := range groups {
+		configMaps := deserializer.NewConfigMapData()
+		for _, m := range group.matches {
+			if seen[m.entry] {
+				continue
+			}
+			seen[m.entry] = true
+			if namespace, name, key, isConfigMap := configMapFromFilename(m.resourceFile); isConfigMap {
+				configMaps.Upsert(namespace, name, key, string(raws[m.entry]))
+			} else if object, ok := objects[m.entry]; ok {
+				result[g] = append(result[g], *object)
+			} else {
+				warnings = append(warnings, Warning{Path: idx.Entries[m.entry].Name, Err: failed[m.entry]})
+			}
 		}
+		flattened, err := configMaps.Flatten()
 		if err != nil {
-			log.Fatal(err)
+			return nil, nil, err
 		}
-		for _, reg := range regs {
-			_, resourceFile := reg.Do(hdr.Name)
-			if resourceFile != "" {
-				if containerName == "" {
-					containerName, _ := containerAndVersionFromFilename(resourceFile)
-					log.Printf("Defaulted container \"%s\"\n", containerName)
-					// TODO: continue looping tar headers and append additional containers to the previous output
-				}
-				return io.NopCloser(tr)
-			}
+		result[g] = append(result[g], flattened...)
+	}
+	return result, warnings, nil
+}
+
+// the resource types found under config/ in the archive, as classified by the index
+func readResourceTypes(idx *Index) *map[string]bool {
+	result := make(map[string]bool)
+	for _, entry := range idx.Entries {
+		if entry.Class != ClassUnknown && strings.HasPrefix(entry.Name, "config/") {
+			result[entry.ResourceType] = true
 		}
 	}
-	return io.NopCloser(tr)
+	return &result
+}
+
+// read the log of a container, the first container found when containerName is empty
+func readLogs(idx *Index, fsys fs.FS, resourceGroup, resourceName, namespace, containerName string, previous bool) (io.Reader, error) {
+	logs := listLogs(idx, resourceName, namespace, containerName, previous)
+	if len(logs) == 0 {
+		return bytes.NewReader(nil), nil
+	}
+	raw, err := fs.ReadFile(fsys, logs[0].Path)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(raw), nil
 }
 
 func wellKnownInsightsJson(resourceGroup string) bool {
@@ -224,9 +400,3 @@ func configMapFromFilename(tarFilePath string) (namespace, name, key string, isC
 	}
 	return parts[2], parts[3], parts[4], true
 }
-
-func resourceTypeFromResourcePath(in string) string {
-	resourceFile := strings.TrimSuffix(in, "/")
-	_, file := path.Split(resourceFile)
-	return strings.TrimSuffix(file, ".json")
-}
@@ -19,11 +19,16 @@ import (
 	"archive/tar"
 	"bytes"
 	"errors"
+	"fmt"
+	"io"
 	"io/fs"
 	"log"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/bverschueren/in2un/internal/testutil"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	v1 "k8s.io/api/core/v1"
@@ -32,8 +37,9 @@ import (
 )
 
 type tarrable struct {
-	Name string
-	Body []byte
+	Name    string
+	Body    []byte
+	ModTime time.Time
 }
 
 func generateBufferedTar(in []tarrable) *bytes.Buffer {
@@ -42,9 +48,10 @@ func generateBufferedTar(in []tarrable) *bytes.Buffer {
 
 	for _, file := range in {
 		hdr := &tar.Header{
-			Name: file.Name,
-			Mode: 0600,
-			Size: int64(len(file.Body)),
+			Name:    file.Name,
+			Mode:    0600,
+			Size:    int64(len(file.Body)),
+			ModTime: file.ModTime,
 		}
 		if err := tw.WriteHeader(hdr); err != nil {
 			log.Fatal(err)
@@ -59,6 +66,36 @@ func generateBufferedTar(in []tarrable) *bytes.Buffer {
 	return &buf
 }
 
+func newBufferedInsightsReader(buf *bytes.Buffer) (*InsightsReader, error) {
+	idx, err := buildIndex(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		return nil, err
+	}
+	return &InsightsReader{Path: "buffer", Index: idx, FS: newTarFS(idx, func(offset int64) (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(buf.Bytes()[offset:])), nil
+	}, func(offset int64) int64 { return offset })}, nil
+}
+
+func mustReadResource(t *testing.T, ir *InsightsReader, resourceGroup, resourceName, namespace, overrideApiVersion, overrideKind string) *unstructured.UnstructuredList {
+	got, _, err := ir.ReadResource(resourceGroup, resourceName, namespace, overrideApiVersion, overrideKind)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func readLogString(t *testing.T, ir *InsightsReader, resourceName, namespace, containerName string) string {
+	r, err := ir.ReadLog("pod", resourceName, namespace, containerName, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(got)
+}
+
 func generateUnstructuredList(u ...unstructured.Unstructured) *unstructured.UnstructuredList {
 	return &unstructured.UnstructuredList{
 		Object: map[string]interface{}{"kind": "List", "apiVersion": "v1"},
@@ -216,8 +253,8 @@ func TestResourceFromInsights(t *testing.T) {
 			overrideApiVersion: "",
 			overrideKind:       "",
 			expected: generateUnstructuredList(
-				generateUnstructuredConfigMap("openshift-install", "openshift-config", map[string]string{"version": "v1.2.3", "invoker": "user"}),
 				generateUnstructuredConfigMap("dummy", "openshift-config", map[string]string{"key": "value"}),
+				generateUnstructuredConfigMap("openshift-install", "openshift-config", map[string]string{"version": "v1.2.3", "invoker": "user"}),
 			),
 		},
 		{
@@ -228,9 +265,9 @@ func TestResourceFromInsights(t *testing.T) {
 			overrideApiVersion: "",
 			overrideKind:       "",
 			expected: generateUnstructuredList(
-				generateUnstructuredConfigMap("openshift-install", "openshift-config", map[string]string{"version": "v1.2.3", "invoker": "user"}),
-				generateUnstructuredConfigMap("dummy", "openshift-config", map[string]string{"key": "value"}),
 				generateUnstructuredConfigMap("dummy", "namespace", map[string]string{"key": "value"}),
+				generateUnstructuredConfigMap("dummy", "openshift-config", map[string]string{"key": "value"}),
+				generateUnstructuredConfigMap("openshift-install", "openshift-config", map[string]string{"version": "v1.2.3", "invoker": "user"}),
 			),
 		},
 		{
@@ -273,26 +310,14 @@ func TestResourceFromInsights(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			tw := generateBufferedTar(files)
-			tr := tar.NewReader(tw)
-			configRegex := NewResourceRegex(tc.resourceGroup, tc.resourceName, tc.namespace,
-				NewConfigRegex(
-					tc.resourceGroup,
-					tc.resourceName,
-					tc.namespace,
-				))
-			conditionalRegex := NewResourceRegex(tc.resourceGroup, tc.resourceName, tc.namespace,
-				NewConditionalRegex(
-					tc.resourceGroup,
-					tc.resourceName,
-					tc.namespace,
-				),
-			)
-			operatorConfigRegex := NewOperatorConfigRegex(
-				tc.resourceGroup,
-				tc.resourceName,
-			)
-			got := readResources(tr, []IRegex{configRegex, conditionalRegex, operatorConfigRegex}, "", "")
+			ir, err := newBufferedInsightsReader(generateBufferedTar(files))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, _, err := ir.ReadResource(tc.resourceGroup, tc.resourceName, tc.namespace, tc.overrideApiVersion, tc.overrideKind)
+			if err != nil {
+				t.Fatal(err)
+			}
 
 			if !reflect.DeepEqual(got, tc.expected) {
 				t.Fatalf("\nExpected: %+v,\n\t got: %+v", tc.expected, got)
@@ -311,7 +336,7 @@ func TestNewInsightsReader(t *testing.T) {
 		{
 			name:        "return an InsightsReader",
 			path:        "../../testdata/fake-insights-archive",
-			expected:    &InsightsReader{Reader: new(tar.Reader), Path: "testdata/fake-insights-archive"},
+			expected:    &InsightsReader{Index: &Index{}, Path: "testdata/fake-insights-archive"},
 			expectedErr: nil,
 		},
 		{
@@ -350,13 +375,13 @@ func TestOpen(t *testing.T) {
 	tests := []struct {
 		name        string
 		path        string
-		expected    *tar.Reader
+		expected    io.ReadCloser
 		expectedErr error
 	}{
 		{
-			name:        "return a tar.Reader",
+			name:        "return an uncompressed tar stream",
 			path:        "../../testdata/fake-insights-archive",
-			expected:    &tar.Reader{},
+			expected:    &readCloser{},
 			expectedErr: nil,
 		},
 		{
@@ -374,7 +399,10 @@ func TestOpen(t *testing.T) {
 	}
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			got, err := open(tc.path)
+			got, err := open(tc.path, nil)
+			if got != nil {
+				defer got.Close()
+			}
 
 			if tc.expectedErr != nil {
 				if !errors.Is(err, tc.expectedErr) {
@@ -428,3 +456,602 @@ func TestContainerAndVersionFromFilename(t *testing.T) {
 		})
 	}
 }
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name                                  string
+		in                                    string
+		class                                 EntryClass
+		resourceType, namespace, resourceName string
+	}{
+		{
+			name:         "classify namespaced resource",
+			in:           "config/pod/openshift-multus/multus-sns4n.json",
+			class:        ClassResource,
+			resourceType: "pod",
+			namespace:    "openshift-multus",
+			resourceName: "multus-sns4n",
+		},
+		{
+			name:         "classify cluster scoped resource",
+			in:           "config/clusteroperator/network.json",
+			class:        ClassResource,
+			resourceType: "clusteroperator",
+			resourceName: "network",
+		},
+		{
+			name:         "classify storage resource",
+			in:           "config/storage/storageclasses/standard-csi.json",
+			class:        ClassResource,
+			resourceType: "storageclasses",
+			resourceName: "standard-csi",
+		},
+		{
+			name:         "classify well-known resource",
+			in:           "config/ingress.json",
+			class:        ClassResource,
+			resourceType: "ingress",
+		},
+		{
+			name:         "classify configmap key",
+			in:           "config/configmaps/openshift-config/openshift-install/version",
+			class:        ClassConfigMap,
+			resourceType: "configmaps",
+			namespace:    "openshift-config",
+			resourceName: "openshift-install",
+		},
+		{
+			name:         "classify conditional resource",
+			in:           "conditional/namespaces/openshift-ingress/pods/router-abc.json",
+			class:        ClassResource,
+			resourceType: "pods",
+			namespace:    "openshift-ingress",
+			resourceName: "router-abc",
+		},
+		{
+			name:         "classify container log",
+			in:           "config/pod/openshift-ingress/logs/router-abc/router_current.log",
+			class:        ClassLog,
+			resourceType: "pod",
+			namespace:    "openshift-ingress",
+			resourceName: "router-abc",
+		},
+		{
+			name:         "classify conditional container log",
+			in:           "conditional/namespaces/openshift-ingress/pods/router-abc/containers/router/logs/last-100-lines.log",
+			class:        ClassLog,
+			resourceType: "pod",
+			namespace:    "openshift-ingress",
+			resourceName: "router-abc",
+		},
+		{
+			name:         "classify namespace events",
+			in:           "events/openshift-ingress.json",
+			class:        ClassEvents,
+			resourceType: "events",
+			namespace:    "openshift-ingress",
+		},
+		{
+			name:  "do not classify unknown files",
+			in:    "insights-operator/gathers.json",
+			class: ClassUnknown,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			class, resourceType, namespace, resourceName := classify(tc.in)
+
+			if class != tc.class || resourceType != tc.resourceType || namespace != tc.namespace || resourceName != tc.resourceName {
+				t.Fatalf("Expected: (%s, %s, %s, %s) got: (%s, %s, %s, %s)", tc.class, tc.resourceType, tc.namespace, tc.resourceName, class, resourceType, namespace, resourceName)
+			}
+		})
+	}
+}
+
+func TestBuildIndex(t *testing.T) {
+	files := []tarrable{
+		{Name: "config/clusteroperator/network.json", Body: []byte(`{}`), ModTime: time.Date(2024, 10, 16, 12, 1, 0, 0, time.UTC)},
+		{Name: "config/pod/openshift-multus/multus-sns4n.json", Body: []byte(`{"metadata":{}}`), ModTime: time.Date(2024, 10, 16, 12, 0, 0, 0, time.UTC)},
+	}
+	buf := generateBufferedTar(files)
+	idx, err := buildIndex(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(idx.Entries) != len(files) {
+		t.Fatalf("Expected %d entries, got %d", len(files), len(idx.Entries))
+	}
+	for i, entry := range idx.Entries {
+		if entry.Name != files[i].Name || entry.Size != int64(len(files[i].Body)) {
+			t.Fatalf("Expected: (%s, %d) got: (%s, %d)", files[i].Name, len(files[i].Body), entry.Name, entry.Size)
+		}
+		got := buf.Bytes()[entry.Offset : entry.Offset+entry.Size]
+		if !bytes.Equal(got, files[i].Body) {
+			t.Fatalf("Expected content at offset %d: %s, got: %s", entry.Offset, files[i].Body, got)
+		}
+	}
+	if expected := files[0].ModTime; !idx.GatherTime.Equal(expected) {
+		t.Fatalf("Expected: gather time %s, got: %s", expected, idx.GatherTime)
+	}
+}
+
+func TestReadResourceTypes(t *testing.T) {
+	files := []tarrable{
+		{Name: "config/version.json", Body: []byte(`{}`)},
+		{Name: "config/node/master-0.json", Body: []byte(`{}`)},
+		{Name: "config/pod/openshift-multus/multus-sns4n.json", Body: []byte(`{}`)},
+		{Name: "config/pod/openshift-multus/logs/multus-sns4n/kube-multus_current.log", Body: []byte("log line")},
+		{Name: "config/storage/storageclasses/standard.json", Body: []byte(`{}`)},
+		{Name: "conditional/namespaces/openshift-ingress/routes/console.json", Body: []byte(`{}`)},
+		{Name: "events/openshift-multus.json", Body: []byte(`{}`)},
+	}
+	ir, err := newBufferedInsightsReader(generateBufferedTar(files))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ir.ReadResourceTypes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]bool{"version": true, "node": true, "pod": true, "storageclasses": true}
+	if !reflect.DeepEqual(*got, expected) {
+		t.Fatalf("Expected: %v, got: %v", expected, *got)
+	}
+}
+
+func TestRepeatableReads(t *testing.T) {
+	fakeObj := []byte(`{"metadata":{},"kind":"FakeKind","apiVersion":"Fake1.2"}`)
+	files := []tarrable{
+		{Name: "config/clusteroperator/network.json", Body: fakeObj},
+		{Name: "config/pod/openshift-multus/multus-sns4n.json", Body: fakeObj},
+		{Name: "config/pod/openshift-multus/logs/multus-sns4n/kube-multus_current.log", Body: []byte("log line")},
+	}
+	ir, err := newBufferedInsightsReader(generateBufferedTar(files))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// query in reverse archive order and repeat to verify nothing is consumed
+	for i := 0; i < 2; i++ {
+		logs := readLogString(t, ir, "multus-sns4n", "openshift-multus", "kube-multus")
+		if string(logs) != "log line" {
+			t.Fatalf("Expected log 'log line', got '%s' on iteration %d", logs, i)
+		}
+		if got := mustReadResource(t, ir, "pod", "", "openshift-multus", "", ""); len(got.Items) != 1 {
+			t.Fatalf("Expected 1 pod, got %d on iteration %d", len(got.Items), i)
+		}
+		if got := mustReadResource(t, ir, "clusteroperator", "", "", "", ""); len(got.Items) != 1 {
+			t.Fatalf("Expected 1 clusteroperator, got %d on iteration %d", len(got.Items), i)
+		}
+	}
+}
+
+func TestDirectoryInsightsReader(t *testing.T) {
+	fakeObj := []byte(`{"metadata":{},"kind":"FakeKind","apiVersion":"Fake1.2"}`)
+	files := []tarrable{
+		{Name: "config/clusteroperator/network.json", Body: fakeObj},
+		{Name: "config/pod/openshift-multus/multus-sns4n.json", Body: fakeObj},
+		{Name: "config/pod/openshift-multus/logs/multus-sns4n/kube-multus_current.log", Body: []byte("log line")},
+		{Name: "config/configmaps/openshift-config/openshift-install/version", Body: []byte("v1.2.3")},
+	}
+	contents := make(map[string]string)
+	for _, file := range files {
+		contents[file.Name] = string(file.Body)
+	}
+	fromDir, err := NewInsightsReader(testutil.WriteArchive(t, contents))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromTar, err := newBufferedInsightsReader(generateBufferedTar(files))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, resourceGroup := range []string{"pod", "clusteroperator", "configmap"} {
+		expected := mustReadResource(t, fromTar, resourceGroup, "", AllNamespaceValue, "", "")
+		got := mustReadResource(t, fromDir, resourceGroup, "", AllNamespaceValue, "", "")
+		if len(got.Items) != 1 || !reflect.DeepEqual(got, expected) {
+			t.Fatalf("Expected: %+v, got: %+v", expected, got)
+		}
+	}
+	logs := readLogString(t, fromDir, "multus-sns4n", "openshift-multus", "kube-multus")
+	if string(logs) != "log line" {
+		t.Fatalf("Expected log 'log line', got '%s'", logs)
+	}
+}
+
+func TestTarFS(t *testing.T) {
+	files := []tarrable{
+		{Name: "config/clusteroperator/network.json", Body: []byte(`{}`)},
+		{Name: "config/ingress.json", Body: []byte(`{"spec":{}}`)},
+	}
+	ir, err := newBufferedInsightsReader(generateBufferedTar(files))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// read out of archive order to verify the stream is reopened
+	for i := len(files) - 1; i >= 0; i-- {
+		got, err := fs.ReadFile(ir.FS, files[i].Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, files[i].Body) {
+			t.Fatalf("Expected: %s, got: %s", files[i].Body, got)
+		}
+	}
+	if _, err := ir.FS.Open("config/non-existing.json"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Expected err='%s', got err='%s'", fs.ErrNotExist, err)
+	}
+}
+
+func TestSequentialReader(t *testing.T) {
+	// entries spread over a stream of zeros, further apart than the distance between checkpoints
+	entries := []IndexEntry{
+		{Name: "a", Offset: 0, Size: 10},
+		{Name: "b", Offset: 2 * checkpointSpan, Size: 10},
+		{Name: "c", Offset: 4 * checkpointSpan, Size: 10},
+		{Name: "b", Offset: 2 * checkpointSpan, Size: 10},
+	}
+	tests := []struct {
+		name          string
+		resume        func(offset int64) int64
+		expectedOpens int
+	}{
+		{
+			name:          "stream without checkpoints is read on when skipping forward",
+			resume:        nil,
+			expectedOpens: 2,
+		},
+		{
+			name:          "stream resumed from a checkpoint is reopened when skipping forward",
+			resume:        func(offset int64) int64 { return offset - offset%checkpointSpan },
+			expectedOpens: 4,
+		},
+		{
+			name:          "checkpoint before the current position is not used",
+			resume:        func(offset int64) int64 { return min(offset, checkpointSpan) },
+			expectedOpens: 3,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			opens := 0
+			sr := newSequentialReader(func(offset int64) (io.ReadCloser, error) {
+				opens++
+				return io.NopCloser(io.LimitReader(zeros{}, 5*checkpointSpan-offset)), nil
+			}, tc.resume)
+			defer sr.Close()
+			for _, entry := range entries {
+				if _, err := sr.ReadEntry(entry); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if opens != tc.expectedOpens {
+				t.Fatalf("Expected: %d opens, got: %d", tc.expectedOpens, opens)
+			}
+		})
+	}
+}
+
+type zeros struct{}
+
+func (zeros) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+func TestReadErrors(t *testing.T) {
+	files := []tarrable{
+		{Name: "config/ingress.json", Body: []byte(`{"metadata":`)},
+		{Name: "config/pod/openshift-multus/logs/multus-sns4n/kube-multus_current.log", Body: []byte("log line")},
+	}
+	buf := generateBufferedTar(files)
+	ir, err := newBufferedInsightsReader(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var parseErr *ParseError
+	if _, _, err := ir.ReadResource("ingress", "", "", "", ""); !errors.As(err, &parseErr) || parseErr.Path != "config/ingress.json" {
+		t.Fatalf("Expected ParseError for config/ingress.json, got err='%s'", err)
+	}
+
+	// cut the archive in the middle of the log entry
+	truncated := buf.Bytes()[:ir.Index.Entries[1].Offset+2]
+	ir.FS = newTarFS(ir.Index, func(offset int64) (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(truncated[offset:])), nil
+	}, nil)
+	var entryErr *EntryError
+	_, err = ir.ReadLog("pod", "multus-sns4n", "openshift-multus", "kube-multus", false)
+	if !errors.As(err, &entryErr) || !errors.Is(err, ErrTruncatedEntry) {
+		t.Fatalf("Expected EntryError wrapping '%s', got err='%s'", ErrTruncatedEntry, err)
+	}
+}
+
+func TestReadResourceWarnings(t *testing.T) {
+	fakeObj := []byte(`{"metadata":{},"kind":"FakeKind","apiVersion":"Fake1.2"}`)
+	files := []tarrable{
+		{Name: "config/pod/openshift-multus/multus-sns4n.json", Body: fakeObj},
+		{Name: "config/pod/openshift-multus/multus-a3e4d.json", Body: []byte(`{"metadata":`)},
+		{Name: "config/pod/openshift-multus/multus-b5f6g.json", Body: []byte(`[]`)},
+	}
+	ir, err := newBufferedInsightsReader(generateBufferedTar(files))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, warnings, err := ir.ReadResource("pod", "", "openshift-multus", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Items) != 1 {
+		t.Fatalf("Expected 1 pod, got %d", len(got.Items))
+	}
+	if len(warnings) != 2 || warnings[0].Path != files[1].Name || warnings[1].Path != files[2].Name {
+		t.Fatalf("Expected warnings for %s and %s, got %v", files[1].Name, files[2].Name, warnings)
+	}
+}
+
+func TestReadResources(t *testing.T) {
+	files := []tarrable{
+		{Name: "config/clusteroperator/network.json", Body: []byte(`{"metadata":{"name":"network"},"kind":"ClusterOperator","apiVersion":"config.openshift.io/v1"}`)},
+		{Name: "config/configmaps/openshift-config/dummy/key", Body: []byte("value")},
+		{Name: "config/pod/openshift-multus/multus-a3e4d.json", Body: []byte(`{"metadata":{"name":"multus-a3e4d","namespace":"openshift-multus"}}`)},
+		{Name: "config/pod/openshift-multus/multus-sns4n.json", Body: []byte(`{"metadata":{"name":"multus-sns4n","namespace":"openshift-multus"}}`)},
+		{Name: "config/version.json", Body: []byte(`{"metadata":{"name":"version"},"kind":"ClusterVersion","apiVersion":"config.openshift.io/v1"}`)},
+	}
+	ir, err := newBufferedInsightsReader(generateBufferedTar(files))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		refs     []ResourceRef
+		expected []string
+	}{
+		{
+			name:     "multiple resource types in order of the request",
+			refs:     []ResourceRef{{ResourceGroup: "pod"}, {ResourceGroup: "configmap"}, {ResourceGroup: "clusteroperator"}},
+			expected: []string{"Pod/multus-a3e4d", "Pod/multus-sns4n", "ConfigMap/dummy", "ClusterOperator/network"},
+		},
+		{
+			name:     "multiple resources by name",
+			refs:     []ResourceRef{{ResourceGroup: "pod", ResourceName: "multus-sns4n"}, {ResourceGroup: "clusteroperator", ResourceName: "network"}},
+			expected: []string{"Pod/multus-sns4n", "ClusterOperator/network"},
+		},
+		{
+			name:     "duplicate resources are returned once",
+			refs:     []ResourceRef{{ResourceGroup: "pod"}, {ResourceGroup: "pod", ResourceName: "multus-sns4n"}},
+			expected: []string{"Pod/multus-a3e4d", "Pod/multus-sns4n"},
+		},
+		{
+			name:     "all resource types, including well-known files",
+			refs:     []ResourceRef{{ResourceGroup: "all"}},
+			expected: []string{"ClusterOperator/network", "Pod/multus-a3e4d", "Pod/multus-sns4n", "ClusterVersion/version", "ConfigMap/dummy"},
+		},
+		{
+			name:     "well-known file by type",
+			refs:     []ResourceRef{{ResourceGroup: "clusterversion"}, {ResourceGroup: "version", ResourceName: "version"}},
+			expected: []string{"ClusterVersion/version"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, _, err := ir.ReadResources(tc.refs, AllNamespaceValue, "", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, item := range got.Items {
+				names = append(names, item.GetKind()+"/"+item.GetName())
+			}
+			if !reflect.DeepEqual(names, tc.expected) {
+				t.Fatalf("Expected: %v, got: %v", tc.expected, names)
+			}
+		})
+	}
+}
+
+func TestReadEvents(t *testing.T) {
+	files := []tarrable{
+		{Name: "events/openshift-etcd.json", Body: []byte(`{"items":[{"namespace":"openshift-etcd","lastTimestamp":"2024-10-16T12:00:00Z","reason":"BackOff","message":"Back-off restarting failed container","type":"Warning"}]}`)},
+		{Name: "events/openshift-multus.json", Body: []byte(`{"items":[{"lastTimestamp":"2024-10-16T11:00:00Z","reason":"Started","message":"Started container","type":"Normal","involvedObject":{"kind":"Pod","name":"multus-sns4n"},"count":3}]}`)},
+		{Name: "events/broken.json", Body: []byte(`{"items":`)},
+	}
+	ir, err := newBufferedInsightsReader(generateBufferedTar(files))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name, namespace  string
+		expected         []string
+		expectedWarnings int
+	}{
+		{name: "events of a namespace", namespace: "openshift-etcd", expected: []string{"openshift-etcd/openshift-etcd.0/BackOff/1"}},
+		{name: "events with involved object and count", namespace: "openshift-multus", expected: []string{"openshift-multus/openshift-multus.0/Started/3"}},
+		{name: "events of all namespaces", namespace: AllNamespaceValue, expected: []string{"openshift-etcd/openshift-etcd.0/BackOff/1", "openshift-multus/openshift-multus.0/Started/3"}, expectedWarnings: 1},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, warnings, err := ir.ReadEvents(tc.namespace)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var events []string
+			for _, event := range got.Items {
+				if event.GetKind() != "Event" || event.GetAPIVersion() != "v1" {
+					t.Fatalf("Expected: v1/Event, got: %s/%s", event.GetAPIVersion(), event.GetKind())
+				}
+				reason, _, _ := unstructured.NestedString(event.Object, "reason")
+				count, _, _ := unstructured.NestedInt64(event.Object, "count")
+				events = append(events, fmt.Sprintf("%s/%s/%s/%d", event.GetNamespace(), event.GetName(), reason, count))
+			}
+			if !reflect.DeepEqual(events, tc.expected) {
+				t.Fatalf("Expected: %v, got: %v", tc.expected, events)
+			}
+			if len(warnings) != tc.expectedWarnings {
+				t.Fatalf("Expected %d warnings, got: %v", tc.expectedWarnings, warnings)
+			}
+		})
+	}
+}
+
+func TestListLogs(t *testing.T) {
+	files := []tarrable{
+		{Name: "config/pod/openshift-multus/logs/multus-sns4n/kube-multus_current.log", Body: []byte("multus")},
+		{Name: "config/pod/openshift-multus/logs/multus-sns4n/kube-rbac-proxy_current.log", Body: []byte("proxy")},
+		{Name: "config/pod/openshift-multus/logs/multus-sns4n/kube-multus_previous.log", Body: []byte("previous multus")},
+		{Name: "conditional/namespaces/openshift-multus/pods/multus-sns4n/containers/kube-multus/logs/last-100-lines.log", Body: []byte("conditional multus")},
+		{Name: "conditional/namespaces/openshift-multus/pods/multus-sns4n/containers/kube-multus/logs-previous/last-100-lines.log", Body: []byte("conditional previous multus")},
+		{Name: "config/pod/other/logs/multus-sns4n/kube-multus_current.log", Body: []byte("other multus")},
+	}
+	ir, err := newBufferedInsightsReader(generateBufferedTar(files))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name, namespace, containerName string
+		previous                       bool
+		expected                       []string
+	}{
+		{name: "all containers", namespace: "openshift-multus", expected: []string{"multus", "proxy", "conditional multus"}},
+		{name: "single container", namespace: "openshift-multus", containerName: "kube-rbac-proxy", expected: []string{"proxy"}},
+		{name: "previous", namespace: "openshift-multus", previous: true, expected: []string{"previous multus", "conditional previous multus"}},
+		{name: "all namespaces", namespace: AllNamespaceValue, containerName: "kube-multus", expected: []string{"multus", "other multus", "conditional multus"}},
+		{name: "unknown container", namespace: "openshift-multus", containerName: "fake", expected: nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			for _, l := range ir.ListLogs("multus-sns4n", tc.namespace, tc.containerName, tc.previous) {
+				r, err := ir.ReadContainerLog(l)
+				if err != nil {
+					t.Fatal(err)
+				}
+				raw, err := io.ReadAll(r)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, string(raw))
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Fatalf("Expected: %v, got: %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestReadOwnedPods(t *testing.T) {
+	files := []tarrable{
+		{Name: "config/pod/openshift-dns/dns-default-abc.json", Body: []byte(`{"apiVersion":"v1","kind":"Pod","metadata":{"name":"dns-default-abc","namespace":"openshift-dns","labels":{"dns.operator.openshift.io/daemonset-dns":"default"}}}`)},
+		{Name: "config/pod/openshift-dns/node-resolver-abc.json", Body: []byte(`{"apiVersion":"v1","kind":"Pod","metadata":{"name":"node-resolver-abc","namespace":"openshift-dns","labels":{"dns.operator.openshift.io/daemonset-node-resolver":""},"ownerReferences":[{"apiVersion":"apps/v1","kind":"DaemonSet","name":"node-resolver","uid":"1"}]}}`)},
+		{Name: "config/pod/openshift-ingress/router-default-5f7d-x1.json", Body: []byte(`{"apiVersion":"v1","kind":"Pod","metadata":{"name":"router-default-5f7d-x1","namespace":"openshift-ingress","labels":{"pod-template-hash":"5f7d"},"ownerReferences":[{"apiVersion":"apps/v1","kind":"ReplicaSet","name":"router-default-5f7d","uid":"2"}]}}`)},
+		{Name: "config/pod/openshift-ingress/router-internal-6a8e-x1.json", Body: []byte(`{"apiVersion":"v1","kind":"Pod","metadata":{"name":"router-internal-6a8e-x1","namespace":"openshift-ingress","labels":{"pod-template-hash":"6a8e"},"ownerReferences":[{"apiVersion":"apps/v1","kind":"ReplicaSet","name":"router-internal-6a8e","uid":"3"}]}}`)},
+		{Name: "config/daemonsets/openshift-dns/dns-default.json", Body: []byte(`{"apiVersion":"apps/v1","kind":"DaemonSet","metadata":{"name":"dns-default","namespace":"openshift-dns"},"spec":{"selector":{"matchLabels":{"dns.operator.openshift.io/daemonset-dns":"default"}}}}`)},
+	}
+	ir, err := newBufferedInsightsReader(generateBufferedTar(files))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name, resourceGroup, resourceName, namespace string
+		expected                                     []string
+		expectedErr                                  bool
+	}{
+		{name: "by selector of the owner", resourceGroup: "daemonset", resourceName: "dns-default", namespace: "openshift-dns", expected: []string{"dns-default-abc"}},
+		{name: "by owner reference", resourceGroup: "daemonsets", resourceName: "node-resolver", namespace: "openshift-dns", expected: []string{"node-resolver-abc"}},
+		{name: "deployment by replicaset owner reference", resourceGroup: "deployment", resourceName: "router-default", namespace: "openshift-ingress", expected: []string{"router-default-5f7d-x1"}},
+		{name: "deployment in all namespaces", resourceGroup: "deployment", resourceName: "router-internal", namespace: AllNamespaceValue, expected: []string{"router-internal-6a8e-x1"}},
+		{name: "no pods", resourceGroup: "statefulset", resourceName: "fake", namespace: "openshift-dns", expected: nil},
+		{name: "unsupported type", resourceGroup: "service", resourceName: "router", namespace: "openshift-ingress", expectedErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, _, err := ir.ReadOwnedPods(tc.resourceGroup, tc.resourceName, tc.namespace)
+			if tc.expectedErr {
+				if err == nil {
+					t.Fatalf("Expected: error, got: %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, pod := range got.Items {
+				names = append(names, pod.GetName())
+			}
+			if !reflect.DeepEqual(names, tc.expected) {
+				t.Fatalf("Expected: %v, got: %v", tc.expected, names)
+			}
+		})
+	}
+}
+
+func TestReadAPIResources(t *testing.T) {
+	files := []tarrable{
+		{Name: "config/pod/openshift-dns/dns-default-abc.json", Body: []byte(`{"metadata":{"name":"dns-default-abc","namespace":"openshift-dns"}}`)},
+		{Name: "config/pod/openshift-dns/logs/dns-default-abc/dns_current.log", Body: []byte("log line")},
+		{Name: "conditional/namespaces/openshift-dns/pods/dns-default-abc.json", Body: []byte(`{"metadata":{"name":"dns-default-abc","namespace":"openshift-dns"}}`)},
+		{Name: "conditional/namespaces/openshift-dns/pods/dns-default-def.json", Body: []byte(`{"metadata":{"name":"dns-default-def","namespace":"openshift-dns"}}`)},
+		{Name: "config/clusteroperator/dns.json", Body: []byte(`{"metadata":{}}`)},
+		{Name: "config/ingress.json", Body: []byte(`{"metadata":{}}`)},
+		{Name: "config/configmaps/openshift-config/openshift-install/version", Body: []byte("v4.16.0")},
+		{Name: "config/configmaps/openshift-config/openshift-install/invoker", Body: []byte("user")},
+		{Name: "config/certificatesigningrequests/csr-abc.json", Body: []byte(`{"apiVersion":"certificates.k8s.io/v1","kind":"CertificateSigningRequest","metadata":{}}`)},
+		{Name: "config/fakes/fake.json", Body: []byte(`{"apiVersion":"example.com/v1","kind":"FakeList","items":[]}`)},
+		{Name: "config/widgets/broken.json", Body: []byte(`{"apiVersion":`)},
+		{Name: "config/pod/openshift-dns/dns-default-ghi.json", Body: []byte(`{"metadata":`)},
+		{Name: "config/version.json", Body: []byte(`{"metadata":{"name":"version"}}`)},
+		{Name: "events/openshift-dns.json", Body: []byte(`{"items":[{"reason":"Started"},{"reason":"Killing"}]}`)},
+	}
+	ir, err := newBufferedInsightsReader(generateBufferedTar(files))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, warnings, err := ir.ReadAPIResources()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resources []string
+	for _, resource := range got {
+		resources = append(resources, fmt.Sprintf("%s/%s/%s/%t/%d/%s/%s", resource.Name, resource.APIVersion, resource.Kind, resource.Namespaced, resource.Count, strings.Join(resource.Sources, ","), strings.Join(resource.ShortNames, ",")))
+	}
+	expected := []string{
+		"certificatesigningrequests/certificates.k8s.io/v1/CertificateSigningRequest/false/1/config/",
+		"clusteroperators/config.openshift.io/v1/ClusterOperator/false/1/config/co",
+		"clusterversions/config.openshift.io/v1/ClusterVersion/false/1/config/clusterversion",
+		"configmaps/v1/ConfigMap/true/1/config/cm",
+		"events/v1/Event/true/2/events/",
+		"fakes/example.com/v1/Fake/false/1/config/",
+		"ingresses/config.openshift.io/v1/Ingress/false/1/config/",
+		"pods/v1/Pod/true/2/config,conditional/po",
+		"widgets///false/0/config/",
+	}
+	if !reflect.DeepEqual(resources, expected) {
+		t.Fatalf("Expected: %v, got: %v", expected, resources)
+	}
+	if len(warnings) != 2 || warnings[0].Path != "config/widgets/broken.json" || warnings[1].Path != "config/pod/openshift-dns/dns-default-ghi.json" {
+		t.Fatalf("Expected warnings for config/widgets/broken.json and config/pod/openshift-dns/dns-default-ghi.json, got: %v", warnings)
+	}
+	// every name listed reads the distinct objects counted
+	for _, resource := range got {
+		if resource.Count == 0 || resource.Name == "events" {
+			continue
+		}
+		found, _, err := ir.ReadResource(resource.Name, "", AllNamespaceValue, "", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		distinct := make(map[string]bool)
+		for _, item := range found.Items {
+			distinct[item.GetNamespace()+"/"+item.GetName()] = true
+		}
+		if len(distinct) != resource.Count {
+			t.Fatalf("Expected: %d %s, got: %d", resource.Count, resource.Name, len(distinct))
+		}
+	}
+}
@@ -0,0 +1,157 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package reader
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bverschueren/in2un/pkg/helpers"
+	configv1 "github.com/openshift/api/config/v1"
+	mcfgv1 "github.com/openshift/api/machineconfiguration/v1"
+	log "github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+)
+
+// Scheme holds the types Get and List convert archive objects into: the Kubernetes types of client-go
+// and the config.openshift.io and machineconfiguration.openshift.io types of github.com/openshift/api.
+// Register other types to read them as typed objects too.
+var Scheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(Scheme))
+	utilruntime.Must(configv1.AddToScheme(Scheme))
+	utilruntime.Must(mcfgv1.AddToScheme(Scheme))
+}
+
+// Object is a pointer to a typed API object, e.g. *corev1.Pod
+type Object[T any] interface {
+	*T
+	runtime.Object
+}
+
+// Get returns the object of type T named name, e.g.
+//
+//	pod, err := reader.Get[corev1.Pod](ir, "openshift-etcd", "etcd-master-0")
+//	co, err := reader.Get[configv1.ClusterOperator](ir, "", "network")
+//
+// It fails with a NotFound error (apierrors.IsNotFound) when the archive holds no such object
+// and with a ParseError when the file holding it could not be parsed.
+func Get[T any, PT Object[T]](ir *InsightsReader, namespace, name string) (PT, error) {
+	o := []QueryOption{WithNames(name), WithConditional()}
+	if namespace != "" {
+		o = append(o, WithNamespaces(namespace))
+	}
+	items, warnings, err := List[T, PT](ir, o...)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		if len(warnings) > 0 {
+			return nil, &ParseError{Path: warnings[0].Path, Err: warnings[0].Err}
+		}
+		gvk, _ := kindOf[T, PT]()
+		return nil, apierrors.NewNotFound(schema.GroupResource{Group: gvk.Group, Resource: helpers.ResourceForKind(gvk.Kind)}, name)
+	}
+	return items[0], nil
+}
+
+// List returns the objects of type T selected by the query options, e.g.
+//
+//	nodes, warnings, err := reader.List[corev1.Node](ir, reader.WithLabelSelector("node-role.kubernetes.io/master"))
+//
+// The type is given by T, so the options cannot select a resource type, resource or kind.
+// Objects which do not fit T fail the list with a ConversionError.
+func List[T any, PT Object[T]](ir *InsightsReader, o ...QueryOption) ([]PT, Warnings, error) {
+	return list[T, PT](ir, false, o...)
+}
+
+// ListPartial returns the objects of type T selected by the query options as List does,
+// but reports objects which do not fit T as warnings instead of failing the list.
+func ListPartial[T any, PT Object[T]](ir *InsightsReader, o ...QueryOption) ([]PT, Warnings, error) {
+	return list[T, PT](ir, true, o...)
+}
+
+func list[T any, PT Object[T]](ir *InsightsReader, partial bool, o ...QueryOption) ([]PT, Warnings, error) {
+	gvk, err := kindOf[T, PT]()
+	if err != nil {
+		return nil, nil, err
+	}
+	q := Query{}
+	for _, opt := range o {
+		opt(&q)
+	}
+	if q.ResourceType != "" || !q.Resource.Empty() || !q.Kind.Empty() {
+		return nil, nil, fmt.Errorf("invalid query: the type of the objects is given by %T", PT(nil))
+	}
+	q.Kind = gvk
+	found, warnings, err := ir.RunQuery(q)
+	if err != nil {
+		return nil, nil, err
+	}
+	var result []PT
+	for i := range found.Items {
+		obj, err := convert[T, PT](&found.Items[i], gvk)
+		if err != nil {
+			err.Path = ir.pathOf(q, &found.Items[i])
+			if !partial {
+				return nil, nil, err
+			}
+			log.Debug(err)
+			warnings = append(warnings, Warning{Path: err.Path, Err: err})
+			continue
+		}
+		result = append(result, obj)
+	}
+	return result, warnings, nil
+}
+
+// the archive file holding an object read by q, the object's name when the file cannot be told
+func (ir *InsightsReader) pathOf(q Query, item *unstructured.Unstructured) string {
+	for _, m := range q.matchEntries(ir.Index) {
+		entry := ir.Index.Entries[m.entry]
+		if m.stop || (entry.ResourceName == item.GetName() && entry.Namespace == item.GetNamespace()) {
+			return entry.Name
+		}
+	}
+	return item.GetName()
+}
+
+// the kind T is registered as in the scheme
+func kindOf[T any, PT Object[T]]() (schema.GroupVersionKind, error) {
+	gvks, _, err := Scheme.ObjectKinds(PT(new(T)))
+	if err != nil {
+		return schema.GroupVersionKind{}, fmt.Errorf("%T is not a type registered in reader.Scheme: %w", PT(nil), err)
+	}
+	return gvks[0], nil
+}
+
+// convert an archive object into T. Objects of another version of the kind are converted field by field.
+func convert[T any, PT Object[T]](item *unstructured.Unstructured, gvk schema.GroupVersionKind) (PT, *ConversionError) {
+	if got := item.GroupVersionKind(); got.GroupKind() != gvk.GroupKind() {
+		return nil, &ConversionError{Namespace: item.GetNamespace(), Name: item.GetName(), From: got, To: gvk, Err: errors.New("kind mismatch")}
+	}
+	obj := PT(new(T))
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, obj); err != nil {
+		return nil, &ConversionError{Namespace: item.GetNamespace(), Name: item.GetName(), From: item.GroupVersionKind(), To: gvk, Err: err}
+	}
+	return obj, nil
+}
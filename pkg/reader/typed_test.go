@@ -0,0 +1,124 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package reader
+
+import (
+	"errors"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	mcfgv1 "github.com/openshift/api/machineconfiguration/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+func TestTyped(t *testing.T) {
+	files := []tarrable{
+		{Name: "config/pod/ns1/a.json", Body: []byte(`{"metadata":{"name":"a","namespace":"ns1"},"spec":{"nodeName":"master-0","containers":[{"name":"c1"}]}}`)},
+		{Name: "config/pod/ns1/broken.json", Body: []byte(`{"metadata":{"name":"broken","namespace":"ns1"}`)},
+		{Name: "config/pod/ns2/wrong.json", Body: []byte(`{"metadata":{"name":"wrong","namespace":"ns2"},"spec":{"containers":"c1"}}`)},
+		{Name: "config/node/master-0.json", Body: []byte(`{"metadata":{"name":"master-0","labels":{"node-role.kubernetes.io/master":""}}}`)},
+		{Name: "config/node/worker-0.json", Body: []byte(`{"metadata":{"name":"worker-0","labels":{"node-role.kubernetes.io/worker":""}}}`)},
+		{Name: "config/clusteroperator/network.json", Body: []byte(`{"metadata":{"name":"network"},"status":{"conditions":[{"type":"Degraded","status":"True","message":"broken"}],"versions":[{"name":"operator","version":"4.16.40"}]}}`)},
+		{Name: "config/version.json", Body: []byte(`{"apiVersion":"config.openshift.io/v1","kind":"ClusterVersion","metadata":{"name":"version"},"spec":{"clusterID":"abc"},"status":{"history":[{"state":"Completed","version":"4.16.40","startedTime":"2024-10-16T10:00:00Z"}]}}`)},
+		{Name: "config/machineconfigpools/master.json", Body: []byte(`{"metadata":{"name":"master"},"status":{"machineCount":3,"readyMachineCount":2,"configuration":{"name":"rendered-master"}}}`)},
+	}
+	ir, err := newBufferedInsightsReader(generateBufferedTar(files))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("get pod", func(t *testing.T) {
+		pod, err := Get[corev1.Pod](ir, "ns1", "a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pod.Spec.NodeName != "master-0" || len(pod.Spec.Containers) != 1 || pod.Kind != "Pod" {
+			t.Fatalf("Expected: pod a on master-0, got: %+v", pod)
+		}
+	})
+	t.Run("get clusteroperator", func(t *testing.T) {
+		co, err := Get[configv1.ClusterOperator](ir, "", "network")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(co.Status.Conditions) != 1 || co.Status.Conditions[0].Type != configv1.OperatorDegraded || co.Status.Versions[0].Version != "4.16.40" {
+			t.Fatalf("Expected: degraded network operator, got: %+v", co.Status)
+		}
+	})
+	t.Run("get clusterversion", func(t *testing.T) {
+		cv, err := Get[configv1.ClusterVersion](ir, "", "version")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cv.Spec.ClusterID != "abc" || len(cv.Status.History) != 1 || cv.Status.History[0].StartedTime.IsZero() {
+			t.Fatalf("Expected: cluster abc with one update, got: %+v", cv)
+		}
+	})
+	t.Run("list machineconfigpools", func(t *testing.T) {
+		pools, _, err := List[mcfgv1.MachineConfigPool](ir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(pools) != 1 || pools[0].Status.ReadyMachineCount != 2 || pools[0].Status.Configuration.Name != "rendered-master" {
+			t.Fatalf("Expected: master pool with 2 ready machines, got: %+v", pools)
+		}
+	})
+	t.Run("list nodes with selector", func(t *testing.T) {
+		nodes, _, err := List[corev1.Node](ir, WithLabelSelector("node-role.kubernetes.io/worker"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(nodes) != 1 || nodes[0].Name != "worker-0" {
+			t.Fatalf("Expected: worker-0, got: %+v", nodes)
+		}
+	})
+	t.Run("not found", func(t *testing.T) {
+		_, err := Get[corev1.Pod](ir, "ns1", "z")
+		if !apierrors.IsNotFound(err) {
+			t.Fatalf("Expected: not found error, got: %v", err)
+		}
+	})
+	t.Run("unparseable", func(t *testing.T) {
+		_, err := Get[corev1.Pod](ir, "ns1", "broken")
+		var parseError *ParseError
+		if !errors.As(err, &parseError) {
+			t.Fatalf("Expected: parse error, got: %v", err)
+		}
+	})
+	t.Run("data not fitting the type", func(t *testing.T) {
+		_, _, err := List[corev1.Pod](ir, WithNamespaces("ns2"))
+		var conversionError *ConversionError
+		if !errors.As(err, &conversionError) || conversionError.Name != "wrong" || conversionError.Path != "config/pod/ns2/wrong.json" {
+			t.Fatalf("Expected: conversion error for wrong in config/pod/ns2/wrong.json, got: %v", err)
+		}
+	})
+	t.Run("partial list", func(t *testing.T) {
+		pods, warnings, err := ListPartial[corev1.Pod](ir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var conversionError *ConversionError
+		if len(pods) != 1 || len(warnings) != 2 || warnings[1].Path != "config/pod/ns2/wrong.json" || !errors.As(warnings[1].Err, &conversionError) {
+			t.Fatalf("Expected: pod a and warnings for broken and wrong, got: %d pods, warnings %v", len(pods), warnings)
+		}
+	})
+	t.Run("type given twice", func(t *testing.T) {
+		if _, _, err := List[corev1.Pod](ir, WithResourceType("nodes")); err == nil {
+			t.Fatal("Expected: error, got: nil")
+		}
+	})
+}
@@ -0,0 +1,47 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package reader
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Warning reports an archive entry which was skipped, making the result of a read partial
+type Warning struct {
+	Path string
+	Err  error
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("%s: %s", w.Path, w.Err)
+}
+
+func (w Warning) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string{"path": w.Path, "error": w.Err.Error()})
+}
+
+// Warnings collects all entries skipped during a read
+type Warnings []Warning
+
+// Unstructured returns the warnings in a form which can be embedded in unstructured content
+func (w Warnings) Unstructured() []interface{} {
+	out := make([]interface{}, 0, len(w))
+	for _, warning := range w {
+		out = append(out, map[string]interface{}{"path": warning.Path, "error": warning.Err.Error()})
+	}
+	return out
+}
@@ -0,0 +1,151 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package server
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/bverschueren/in2un/pkg/helpers"
+	"github.com/bverschueren/in2un/pkg/reader"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/version"
+)
+
+// resource is a resource type of the archive as served by the API
+type resource struct {
+	// archiveType is the name of the resource type to read it from the archive
+	archiveType      string
+	gvk              schema.GroupVersionKind
+	plural, singular string
+	shortNames       []string
+	namespaced       bool
+}
+
+func (r resource) groupResource() schema.GroupResource {
+	return schema.GroupResource{Group: r.gvk.Group, Resource: r.plural}
+}
+
+// discovery holds the API groups and resources of the archive
+type discovery struct {
+	resources map[schema.GroupVersionResource]resource
+	version   version.Info
+}
+
+// resource types of which neither the archive nor in2un knows the kind are not served
+func newDiscovery(apiResources []reader.APIResource) *discovery {
+	d := &discovery{
+		resources: make(map[schema.GroupVersionResource]resource),
+		version:   version.Info{Major: "1", GitVersion: "v1.0.0-in2un", Platform: "insights/archive"},
+	}
+	for _, apiResource := range apiResources {
+		gvk := apiResource.GroupVersionKind()
+		if gvk.Kind == "" || gvk.Version == "" {
+			continue
+		}
+		res := resource{
+			archiveType: apiResource.Name,
+			gvk:         gvk,
+			plural:      helpers.ResourceForKind(gvk.Kind),
+			singular:    strings.ToLower(gvk.Kind),
+			shortNames:  apiResource.ShortNames,
+			namespaced:  apiResource.Namespaced,
+		}
+		gvr := gvk.GroupVersion().WithResource(res.plural)
+		if _, ok := d.resources[gvr]; !ok {
+			d.resources[gvr] = res
+		}
+	}
+	return d
+}
+
+func (d *discovery) resource(gvr schema.GroupVersionResource) (resource, bool) {
+	res, ok := d.resources[gvr]
+	return res, ok
+}
+
+// the versions served for each group, sorted by group
+func (d *discovery) groupVersions() map[string][]string {
+	versions := make(map[string][]string)
+	for gvr := range d.resources {
+		if !slices.Contains(versions[gvr.Group], gvr.Version) {
+			versions[gvr.Group] = append(versions[gvr.Group], gvr.Version)
+			slices.Sort(versions[gvr.Group])
+		}
+	}
+	return versions
+}
+
+func (d *discovery) apiVersions() *metav1.APIVersions {
+	return &metav1.APIVersions{
+		TypeMeta: metav1.TypeMeta{Kind: "APIVersions", APIVersion: "v1"},
+		Versions: []string{"v1"},
+		// clients connect directly to the local server
+		ServerAddressByClientCIDRs: []metav1.ServerAddressByClientCIDR{},
+	}
+}
+
+func (d *discovery) apiGroupList() *metav1.APIGroupList {
+	list := &metav1.APIGroupList{TypeMeta: metav1.TypeMeta{Kind: "APIGroupList", APIVersion: "v1"}, Groups: []metav1.APIGroup{}}
+	versions := d.groupVersions()
+	for _, group := range slices.Sorted(maps.Keys(versions)) {
+		if group != "" {
+			g, _ := d.apiGroup(group)
+			list.Groups = append(list.Groups, *g)
+		}
+	}
+	return list
+}
+
+func (d *discovery) apiGroup(group string) (*metav1.APIGroup, bool) {
+	versions, ok := d.groupVersions()[group]
+	if !ok || group == "" {
+		return nil, false
+	}
+	g := &metav1.APIGroup{TypeMeta: metav1.TypeMeta{Kind: "APIGroup", APIVersion: "v1"}, Name: group}
+	for _, v := range versions {
+		g.Versions = append(g.Versions, metav1.GroupVersionForDiscovery{GroupVersion: group + "/" + v, Version: v})
+	}
+	g.PreferredVersion = g.Versions[0]
+	return g, true
+}
+
+func (d *discovery) apiResourceList(gv schema.GroupVersion) (*metav1.APIResourceList, bool) {
+	list := &metav1.APIResourceList{TypeMeta: metav1.TypeMeta{Kind: "APIResourceList", APIVersion: "v1"}, GroupVersion: gv.String(), APIResources: []metav1.APIResource{}}
+	for gvr, res := range d.resources {
+		if gvr.GroupVersion() != gv {
+			continue
+		}
+		list.APIResources = append(list.APIResources, metav1.APIResource{
+			Name:         res.plural,
+			SingularName: res.singular,
+			ShortNames:   res.shortNames,
+			Namespaced:   res.namespaced,
+			Kind:         res.gvk.Kind,
+			Verbs:        metav1.Verbs{"get", "list"},
+		})
+		if res.gvk.Kind == "Pod" {
+			list.APIResources = append(list.APIResources, metav1.APIResource{Name: res.plural + "/log", Namespaced: true, Kind: "Pod", Verbs: metav1.Verbs{"get"}})
+		}
+	}
+	if len(list.APIResources) == 0 && gv != (schema.GroupVersion{Version: "v1"}) {
+		return nil, false
+	}
+	slices.SortFunc(list.APIResources, func(a, b metav1.APIResource) int { return strings.Compare(a.Name, b.Name) })
+	return list, true
+}
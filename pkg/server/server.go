@@ -0,0 +1,336 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bverschueren/in2un/pkg/filter"
+	"github.com/bverschueren/in2un/pkg/logs"
+	"github.com/bverschueren/in2un/pkg/output"
+	"github.com/bverschueren/in2un/pkg/reader"
+	"github.com/bverschueren/in2un/pkg/table"
+	log "github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/version"
+)
+
+// Server serves the content of an insights archive as a read-only Kubernetes API
+type Server struct {
+	ir        *reader.InsightsReader
+	discovery *discovery
+}
+
+// NewServer returns a handler serving the API discovery, list, get and pod log endpoints of the archive read by ir
+func NewServer(ir *reader.InsightsReader) (*Server, error) {
+	resources, warnings, err := ir.ReadAPIResources()
+	if err != nil {
+		return nil, err
+	}
+	for _, warning := range warnings {
+		log.Info(warning)
+	}
+	d := newDiscovery(resources)
+	if info, ok := kubernetesVersion(ir); ok {
+		d.version = info
+	}
+	return &Server{ir: ir, discovery: d}, nil
+}
+
+// the version of the cluster as reported by the kubelet of its nodes, e.g. v1.29.5+4b2c1e8
+func kubernetesVersion(ir *reader.InsightsReader) (version.Info, bool) {
+	nodes, _, err := ir.ReadResource("node", "", "", "", "")
+	if err != nil || len(nodes.Items) == 0 {
+		return version.Info{}, false
+	}
+	gitVersion, _, _ := unstructured.NestedString(nodes.Items[0].Object, "status", "nodeInfo", "kubeletVersion")
+	major, minor, ok := strings.Cut(strings.TrimPrefix(gitVersion, "v"), ".")
+	if !ok {
+		return version.Info{}, false
+	}
+	minor, _, _ = strings.Cut(minor, ".")
+	return version.Info{Major: major, Minor: minor, GitVersion: gitVersion, Platform: "insights/archive"}, true
+}
+
+// request holds the parts of a resource request path, e.g. /apis/<group>/<version>/namespaces/<namespace>/<resource>/<name>/<subresource>
+type request struct {
+	gv                                     schema.GroupVersion
+	namespace, resource, name, subresource string
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Debugf("%s %s", r.Method, r.URL)
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, &apierrors.StatusError{ErrStatus: metav1.Status{
+			Status:  metav1.StatusFailure,
+			Code:    http.StatusMethodNotAllowed,
+			Reason:  metav1.StatusReasonMethodNotAllowed,
+			Message: fmt.Sprintf("%s is not supported, the insights archive is served read-only", r.Method),
+		}})
+		return
+	}
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case r.URL.Path == "/version":
+		writeJSON(w, http.StatusOK, s.discovery.version)
+	case r.URL.Path == "/api":
+		writeJSON(w, http.StatusOK, s.discovery.apiVersions())
+	case r.URL.Path == "/apis":
+		writeJSON(w, http.StatusOK, s.discovery.apiGroupList())
+	case len(parts) == 2 && parts[0] == "api":
+		s.serveResourceList(w, schema.GroupVersion{Version: parts[1]})
+	case len(parts) == 2 && parts[0] == "apis":
+		group, ok := s.discovery.apiGroup(parts[1])
+		if !ok {
+			writeError(w, apierrors.NewNotFound(schema.GroupResource{Group: parts[1]}, ""))
+			return
+		}
+		writeJSON(w, http.StatusOK, group)
+	case len(parts) == 3 && parts[0] == "apis":
+		s.serveResourceList(w, schema.GroupVersion{Group: parts[1], Version: parts[2]})
+	case len(parts) > 2 && parts[0] == "api":
+		s.serveResource(w, r, parseRequest(schema.GroupVersion{Version: parts[1]}, parts[2:]))
+	case len(parts) > 3 && parts[0] == "apis":
+		s.serveResource(w, r, parseRequest(schema.GroupVersion{Group: parts[1], Version: parts[2]}, parts[3:]))
+	default:
+		writeError(w, apierrors.NewNotFound(schema.GroupResource{}, r.URL.Path))
+	}
+}
+
+// parse the resource part of a request path, namespaces themselves are requested as /api/v1/namespaces/<name>
+func parseRequest(gv schema.GroupVersion, parts []string) request {
+	req := request{gv: gv}
+	if len(parts) > 2 && parts[0] == "namespaces" {
+		req.namespace, parts = parts[1], parts[2:]
+	}
+	req.resource = parts[0]
+	if len(parts) > 1 {
+		req.name = parts[1]
+	}
+	if len(parts) > 2 {
+		req.subresource = strings.Join(parts[2:], "/")
+	}
+	return req
+}
+
+func (s *Server) serveResourceList(w http.ResponseWriter, gv schema.GroupVersion) {
+	list, ok := s.discovery.apiResourceList(gv)
+	if !ok {
+		writeError(w, apierrors.NewNotFound(schema.GroupResource{Group: gv.Group}, gv.Version))
+		return
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *Server) serveResource(w http.ResponseWriter, r *http.Request, req request) {
+	res, ok := s.discovery.resource(req.gv.WithResource(req.resource))
+	if !ok {
+		writeError(w, apierrors.NewNotFound(req.gv.WithResource(req.resource).GroupResource(), req.name))
+		return
+	}
+	query := r.URL.Query()
+	if query.Get("watch") == "true" || query.Get("watch") == "1" {
+		writeError(w, apierrors.NewMethodNotSupported(res.groupResource(), "watch"))
+		return
+	}
+	switch {
+	case req.subresource == "log" && res.gvk.Kind == "Pod":
+		s.serveLog(w, req, query.Get("container"), query)
+	case req.subresource != "":
+		writeError(w, apierrors.NewNotFound(res.groupResource(), req.name+"/"+req.subresource))
+	default:
+		s.serveObjects(w, r, req, res)
+	}
+}
+
+// serve a single object or a list, as json or as table when the client asks for it (e.g. kubectl get)
+func (s *Server) serveObjects(w http.ResponseWriter, r *http.Request, req request, res resource) {
+	query := r.URL.Query()
+	q := reader.Query{
+		ResourceType:       res.archiveType,
+		LabelSelector:      query.Get("labelSelector"),
+		FieldSelector:      query.Get("fieldSelector"),
+		IncludeConditional: true,
+		OverrideAPIVersion: res.gvk.GroupVersion().String(),
+		OverrideKind:       res.gvk.Kind,
+	}
+	if req.name != "" {
+		q.Names = []string{req.name}
+	}
+	if req.namespace != "" {
+		q.Namespaces = []string{req.namespace}
+	}
+	// validate the selectors upfront to tell bad requests from failures to read the archive
+	empty := &unstructured.UnstructuredList{}
+	if _, err := filter.ByLabels(empty, q.LabelSelector); err != nil {
+		writeError(w, apierrors.NewBadRequest(err.Error()))
+		return
+	}
+	if _, err := filter.ByFields(empty, q.FieldSelector); err != nil {
+		writeError(w, apierrors.NewBadRequest(err.Error()))
+		return
+	}
+	found, warnings, err := s.ir.RunQuery(q)
+	if err != nil {
+		writeError(w, apierrors.NewInternalError(err))
+		return
+	}
+	for _, warning := range warnings {
+		log.Info(warning)
+	}
+	output.SortByName(found)
+	if req.name != "" && len(found.Items) == 0 {
+		writeError(w, apierrors.NewNotFound(res.groupResource(), req.name))
+		return
+	}
+	if acceptsTable(r.Header.Get("Accept")) {
+		writeJSON(w, http.StatusOK, toTable(found.Items, s.ir.ReferenceTime()))
+		return
+	}
+	if req.name != "" {
+		writeJSON(w, http.StatusOK, found.Items[0].Object)
+		return
+	}
+	found.SetAPIVersion(res.gvk.GroupVersion().String())
+	found.SetKind(res.gvk.Kind + "List")
+	found.SetResourceVersion("")
+	if found.Items == nil {
+		found.Items = []unstructured.Unstructured{}
+	}
+	writeJSON(w, http.StatusOK, found)
+}
+
+// serve the log of a container like the kubelet does, supporting the container, previous, tailLines, sinceSeconds and sinceTime parameters.
+// Of the logs gathered for a container, the pod log is preferred over the one of the conditional gatherer.
+func (s *Server) serveLog(w http.ResponseWriter, req request, container string, query map[string][]string) {
+	previous := first(query, "previous") == "true"
+	available := s.ir.ListLogs(req.name, req.namespace, "", previous)
+	var containers []string
+	for _, l := range available {
+		if !slices.Contains(containers, l.Container) {
+			containers = append(containers, l.Container)
+		}
+	}
+	switch {
+	case len(containers) == 0:
+		writeError(w, apierrors.NewNotFound(schema.GroupResource{Resource: "pods/log"}, req.name))
+		return
+	case container == "" && len(containers) > 1:
+		writeError(w, apierrors.NewBadRequest(fmt.Sprintf("a container name must be specified for pod %s, choose one of: %v", req.name, containers)))
+		return
+	case container == "":
+		container = containers[0]
+	case !slices.Contains(containers, container):
+		writeError(w, apierrors.NewBadRequest(fmt.Sprintf("container %s is not valid for pod %s", container, req.name)))
+		return
+	}
+	f := logs.Filter{Tail: -1, Reference: s.ir.GatherTime()}
+	if tail := first(query, "tailLines"); tail != "" {
+		n, err := strconv.ParseInt(tail, 10, 64)
+		if err != nil {
+			writeError(w, apierrors.NewBadRequest(fmt.Sprintf("invalid tailLines '%s'", tail)))
+			return
+		}
+		f.Tail = n
+	}
+	if since := first(query, "sinceSeconds"); since != "" {
+		seconds, err := strconv.ParseInt(since, 10, 64)
+		if err != nil {
+			writeError(w, apierrors.NewBadRequest(fmt.Sprintf("invalid sinceSeconds '%s'", since)))
+			return
+		}
+		if s.ir.GatherTime().IsZero() {
+			writeError(w, apierrors.NewBadRequest("unable to determine when the insights archive was gathered, use sinceTime instead of sinceSeconds"))
+			return
+		}
+		// relative to the time the archive was gathered, not to now
+		f.Since = s.ir.GatherTime().Add(-time.Duration(seconds) * time.Second)
+	}
+	if since := first(query, "sinceTime"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			writeError(w, apierrors.NewBadRequest(fmt.Sprintf("invalid sinceTime '%s'", since)))
+			return
+		}
+		f.Since = t
+	}
+	i := slices.IndexFunc(available, func(l reader.ContainerLog) bool { return l.Container == container })
+	content, err := s.ir.ReadContainerLog(available[i])
+	if err != nil {
+		writeError(w, apierrors.NewInternalError(err))
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	if err := f.Copy(w, content, ""); err != nil {
+		log.Error(err)
+	}
+}
+
+func first(query map[string][]string, key string) string {
+	if values := query[key]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// whether the client asks for a server side printed table, e.g. 'application/json;as=Table;v=v1;g=meta.k8s.io'
+func acceptsTable(accept string) bool {
+	for _, mediaType := range strings.Split(accept, ",") {
+		if strings.Contains(mediaType, "as=Table") && strings.Contains(mediaType, "g=meta.k8s.io") {
+			return true
+		}
+	}
+	return false
+}
+
+func toTable(items []unstructured.Unstructured, now time.Time) *metav1.Table {
+	t := table.ToTable(items, now)
+	t.APIVersion, t.Kind = "meta.k8s.io/v1", "Table"
+	for i := range t.Rows {
+		// RawExtension only serializes its raw content
+		raw, err := json.Marshal(items[i].Object)
+		if err != nil {
+			log.Error(err)
+			continue
+		}
+		t.Rows[i].Object = runtime.RawExtension{Raw: raw}
+	}
+	return t
+}
+
+func writeJSON(w http.ResponseWriter, code int, obj interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(obj); err != nil {
+		log.Error(err)
+	}
+}
+
+func writeError(w http.ResponseWriter, err *apierrors.StatusError) {
+	status := err.Status()
+	status.APIVersion, status.Kind = "v1", "Status"
+	writeJSON(w, int(status.Code), status)
+}
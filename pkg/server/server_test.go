@@ -0,0 +1,248 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package server
+
+import (
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bverschueren/in2un/internal/testutil"
+	"github.com/bverschueren/in2un/pkg/reader"
+)
+
+func newTestServer(t *testing.T, files map[string]string) *Server {
+	ir, err := reader.NewInsightsReader(testutil.WriteArchive(t, files))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ir.Close() })
+	s, err := NewServer(ir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestServeHTTP(t *testing.T) {
+	s := newTestServer(t, map[string]string{
+		"config/node/master-0.json":            `{"apiVersion":"v1","kind":"Node","metadata":{"name":"master-0"},"status":{"nodeInfo":{"kubeletVersion":"v1.29.5+4b2c1e8"}}}`,
+		"config/pod/ns1/a.json":                `{"apiVersion":"v1","kind":"Pod","metadata":{"name":"a","namespace":"ns1","labels":{"app":"web"},"creationTimestamp":"2024-10-16T10:00:00Z"},"status":{"phase":"Running"}}`,
+		"config/pod/ns1/b.json":                `{"apiVersion":"v1","kind":"Pod","metadata":{"name":"b","namespace":"ns1"},"status":{"phase":"Pending"}}`,
+		"config/pod/ns2/c.json":                `{"apiVersion":"v1","kind":"Pod","metadata":{"name":"c","namespace":"ns2"},"status":{"phase":"Running"}}`,
+		"config/pod/ns1/logs/a/c1_current.log": "line1\nline2\nline3\n",
+		"config/pod/ns1/logs/b/c1_current.log": "b1\n",
+		"config/pod/ns1/logs/b/c2_current.log": "b2\n",
+		"config/clusteroperators/network.json": `{"apiVersion":"config.openshift.io/v1","kind":"ClusterOperator","metadata":{"name":"network"}}`,
+	})
+	tests := []struct {
+		name             string
+		method           string
+		path             string
+		accept           string
+		expectedCode     int
+		expectedContains []string
+		expectedMissing  []string
+	}{
+		{
+			name:             "version",
+			path:             "/version",
+			expectedCode:     http.StatusOK,
+			expectedContains: []string{`"gitVersion":"v1.29.5+4b2c1e8"`, `"minor":"29"`},
+		},
+		{
+			name:             "core api versions",
+			path:             "/api",
+			expectedCode:     http.StatusOK,
+			expectedContains: []string{`"versions":["v1"]`},
+		},
+		{
+			name:             "api groups",
+			path:             "/apis",
+			expectedCode:     http.StatusOK,
+			expectedContains: []string{`"name":"config.openshift.io"`},
+		},
+		{
+			name:             "core resources",
+			path:             "/api/v1",
+			expectedCode:     http.StatusOK,
+			expectedContains: []string{`"name":"pods"`, `"name":"pods/log"`, `"name":"nodes"`, `"shortNames":["po"]`},
+		},
+		{
+			name:             "group resources",
+			path:             "/apis/config.openshift.io/v1",
+			expectedCode:     http.StatusOK,
+			expectedContains: []string{`"name":"clusteroperators"`, `"shortNames":["co"]`},
+		},
+		{
+			name:         "unknown group",
+			path:         "/apis/fake.io",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:             "list in all namespaces",
+			path:             "/api/v1/pods",
+			expectedCode:     http.StatusOK,
+			expectedContains: []string{`"kind":"PodList"`, `"name":"a"`, `"name":"b"`, `"name":"c"`},
+		},
+		{
+			name:             "list in a namespace",
+			path:             "/api/v1/namespaces/ns2/pods",
+			expectedCode:     http.StatusOK,
+			expectedContains: []string{`"name":"c"`},
+			expectedMissing:  []string{`"name":"a"`},
+		},
+		{
+			name:             "label selector",
+			path:             "/api/v1/pods?labelSelector=app%3Dweb",
+			expectedCode:     http.StatusOK,
+			expectedContains: []string{`"name":"a"`},
+			expectedMissing:  []string{`"name":"b"`, `"name":"c"`},
+		},
+		{
+			name:             "field selector",
+			path:             "/api/v1/pods?fieldSelector=status.phase%3DPending",
+			expectedCode:     http.StatusOK,
+			expectedContains: []string{`"name":"b"`},
+			expectedMissing:  []string{`"name":"a"`, `"name":"c"`},
+		},
+		{
+			name:             "get",
+			path:             "/api/v1/namespaces/ns1/pods/a",
+			expectedCode:     http.StatusOK,
+			expectedContains: []string{`"kind":"Pod"`, `"name":"a"`},
+			expectedMissing:  []string{`"items"`},
+		},
+		{
+			name:             "get cluster scoped",
+			path:             "/apis/config.openshift.io/v1/clusteroperators/network",
+			expectedCode:     http.StatusOK,
+			expectedContains: []string{`"kind":"ClusterOperator"`},
+		},
+		{
+			name:         "get missing",
+			path:         "/api/v1/namespaces/ns1/pods/z",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:             "table",
+			path:             "/api/v1/namespaces/ns1/pods",
+			accept:           "application/json;as=Table;v=v1;g=meta.k8s.io,application/json",
+			expectedCode:     http.StatusOK,
+			expectedContains: []string{`"kind":"Table"`, `"columnDefinitions"`, `"120m"`},
+		},
+		{
+			name:             "log",
+			path:             "/api/v1/namespaces/ns1/pods/a/log?tailLines=1",
+			expectedCode:     http.StatusOK,
+			expectedContains: []string{"line3"},
+			expectedMissing:  []string{"line2"},
+		},
+		{
+			name:         "log without container",
+			path:         "/api/v1/namespaces/ns1/pods/b/log",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:             "log of container",
+			path:             "/api/v1/namespaces/ns1/pods/b/log?container=c2",
+			expectedCode:     http.StatusOK,
+			expectedContains: []string{"b2"},
+		},
+		{
+			name:         "watch",
+			path:         "/api/v1/pods?watch=true",
+			expectedCode: http.StatusMethodNotAllowed,
+		},
+		{
+			name:         "delete",
+			method:       http.MethodDelete,
+			path:         "/api/v1/namespaces/ns1/pods/a",
+			expectedCode: http.StatusMethodNotAllowed,
+		},
+		{
+			name:         "create",
+			method:       http.MethodPost,
+			path:         "/api/v1/namespaces/ns1/pods",
+			expectedCode: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			method := tc.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, tc.path, nil)
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, req)
+			if rec.Code != tc.expectedCode {
+				t.Fatalf("Expected: %d, got: %d (%s)", tc.expectedCode, rec.Code, rec.Body.String())
+			}
+			body := strings.ReplaceAll(rec.Body.String(), " ", "")
+			for _, s := range tc.expectedContains {
+				if !strings.Contains(body, s) {
+					t.Fatalf("Expected: %s in response, got: %s", s, body)
+				}
+			}
+			for _, s := range tc.expectedMissing {
+				if strings.Contains(body, s) {
+					t.Fatalf("Expected: no %s in response, got: %s", s, body)
+				}
+			}
+		})
+	}
+}
+
+func TestLogSinceWithoutGatherTime(t *testing.T) {
+	dir := testutil.WriteArchive(t, map[string]string{
+		"config/pod/ns1/a.json":                `{"apiVersion":"v1","kind":"Pod","metadata":{"name":"a","namespace":"ns1"}}`,
+		"config/pod/ns1/logs/a/c1_current.log": "2024-10-16T11:00:00Z line1\n",
+	})
+	// modification times at the epoch leave the gather time unknown
+	err := filepath.WalkDir(dir, func(path string, _ fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		return os.Chtimes(path, time.Unix(0, 0), time.Unix(0, 0))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ir, err := reader.NewInsightsReader(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ir.Close()
+	s, err := NewServer(ir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/namespaces/ns1/pods/a/log?sinceSeconds=60", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("Expected: %d, got: %d (%s)", http.StatusBadRequest, rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,379 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package summary
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bverschueren/in2un/pkg/helpers"
+	"github.com/bverschueren/in2un/pkg/reader"
+	configv1 "github.com/openshift/api/config/v1"
+	mcfgv1 "github.com/openshift/api/machineconfiguration/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+// Summary is an overview of the cluster an insights archive was gathered from
+type Summary struct {
+	ClusterID            string              `json:"clusterID"`
+	Version              string              `json:"version"`
+	Channel              string              `json:"channel,omitempty"`
+	UpdateHistory        []Update            `json:"updateHistory"`
+	Platform             string              `json:"platform"`
+	InfrastructureName   string              `json:"infrastructureName,omitempty"`
+	ControlPlaneTopology string              `json:"controlPlaneTopology,omitempty"`
+	APIServerURL         string              `json:"apiServerURL,omitempty"`
+	NetworkType          string              `json:"networkType"`
+	Nodes                Nodes               `json:"nodes"`
+	UnhealthyOperators   []Operator          `json:"unhealthyOperators"`
+	UnhealthyPools       []MachineConfigPool `json:"unhealthyMachineConfigPools"`
+	// GatherTime is when the archive was gathered, nil when unknown
+	GatherTime *time.Time `json:"gatherTime,omitempty"`
+}
+
+// Update is an update applied to the cluster
+type Update struct {
+	Version   string     `json:"version"`
+	State     string     `json:"state"`
+	Started   *time.Time `json:"started,omitempty"`
+	Completed *time.Time `json:"completed,omitempty"`
+}
+
+// Nodes counts the nodes of the cluster, in total and by role
+type Nodes struct {
+	Total int        `json:"total"`
+	Ready int        `json:"ready"`
+	Roles []NodeRole `json:"roles"`
+}
+
+// NodeRole counts the nodes with a role, nodes with several roles count for each
+type NodeRole struct {
+	Role     string `json:"role"`
+	Total    int    `json:"total"`
+	Ready    int    `json:"ready"`
+	NotReady int    `json:"notReady"`
+}
+
+// Operator is a cluster operator which is unavailable or degraded
+type Operator struct {
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	Available   string `json:"available"`
+	Progressing string `json:"progressing"`
+	Degraded    string `json:"degraded"`
+	Message     string `json:"message"`
+}
+
+// MachineConfigPool is a pool which is degraded or not fully updated
+type MachineConfigPool struct {
+	Name                 string `json:"name"`
+	Updated              string `json:"updated"`
+	Updating             string `json:"updating"`
+	Degraded             string `json:"degraded"`
+	MachineCount         int32  `json:"machineCount"`
+	ReadyMachineCount    int32  `json:"readyMachineCount"`
+	UpdatedMachineCount  int32  `json:"updatedMachineCount"`
+	DegradedMachineCount int32  `json:"degradedMachineCount"`
+	Message              string `json:"message"`
+}
+
+const nodeRoleLabelPrefix = "node-role.kubernetes.io/"
+
+// Summarize reads the cluster overview from the archive. Parts missing from the archive are left empty,
+// objects which could not be parsed or do not fit their type are reported as warnings.
+func Summarize(ir *reader.InsightsReader) (*Summary, reader.Warnings, error) {
+	s := &Summary{UpdateHistory: []Update{}, Nodes: Nodes{Roles: []NodeRole{}}, UnhealthyOperators: []Operator{}, UnhealthyPools: []MachineConfigPool{}}
+	var warnings reader.Warnings
+	if t := ir.GatherTime(); !t.IsZero() {
+		s.GatherTime = &t
+	}
+
+	cv, err := reader.Get[configv1.ClusterVersion](ir, "", "version")
+	if warnings, err = skip(warnings, err); err != nil {
+		return nil, nil, err
+	}
+	if cv != nil {
+		s.ClusterID, s.Version, s.Channel = string(cv.Spec.ClusterID), cv.Status.Desired.Version, cv.Spec.Channel
+		for _, h := range cv.Status.History {
+			update := Update{Version: h.Version, State: string(h.State)}
+			if !h.StartedTime.IsZero() {
+				update.Started = &h.StartedTime.Time
+			}
+			if h.CompletionTime != nil {
+				update.Completed = &h.CompletionTime.Time
+			}
+			s.UpdateHistory = append(s.UpdateHistory, update)
+		}
+	}
+
+	infra, err := reader.Get[configv1.Infrastructure](ir, "", "cluster")
+	if warnings, err = skip(warnings, err); err != nil {
+		return nil, nil, err
+	}
+	if infra != nil {
+		s.Platform = string(infra.Status.Platform)
+		if infra.Status.PlatformStatus != nil && infra.Status.PlatformStatus.Type != "" {
+			s.Platform = string(infra.Status.PlatformStatus.Type)
+		}
+		s.InfrastructureName, s.ControlPlaneTopology, s.APIServerURL = infra.Status.InfrastructureName, string(infra.Status.ControlPlaneTopology), infra.Status.APIServerURL
+	}
+
+	network, err := reader.Get[configv1.Network](ir, "", "cluster")
+	if warnings, err = skip(warnings, err); err != nil {
+		return nil, nil, err
+	}
+	if network != nil {
+		// the status holds the network type in use, the spec the one asked for (e.g. during a migration)
+		s.NetworkType = network.Status.NetworkType
+		if s.NetworkType == "" {
+			s.NetworkType = network.Spec.NetworkType
+		}
+	}
+
+	nodes, nodeWarnings, err := reader.ListPartial[corev1.Node](ir)
+	if err != nil {
+		return nil, nil, err
+	}
+	warnings = append(warnings, nodeWarnings...)
+	s.Nodes = countNodes(nodes)
+
+	operators, operatorWarnings, err := reader.ListPartial[configv1.ClusterOperator](ir)
+	if err != nil {
+		return nil, nil, err
+	}
+	warnings = append(warnings, operatorWarnings...)
+	for _, co := range operators {
+		if operator, unhealthy := unhealthyOperator(co); unhealthy {
+			s.UnhealthyOperators = append(s.UnhealthyOperators, operator)
+		}
+	}
+
+	pools, poolWarnings, err := reader.ListPartial[mcfgv1.MachineConfigPool](ir)
+	if err != nil {
+		return nil, nil, err
+	}
+	warnings = append(warnings, poolWarnings...)
+	for _, mcp := range pools {
+		if pool, unhealthy := unhealthyPool(mcp); unhealthy {
+			s.UnhealthyPools = append(s.UnhealthyPools, pool)
+		}
+	}
+	slices.SortFunc(s.UnhealthyOperators, func(a, b Operator) int { return strings.Compare(a.Name, b.Name) })
+	slices.SortFunc(s.UnhealthyPools, func(a, b MachineConfigPool) int { return strings.Compare(a.Name, b.Name) })
+	return s, warnings, nil
+}
+
+// objects missing from the archive leave their part of the summary empty, unparseable ones are reported as warning
+func skip(warnings reader.Warnings, err error) (reader.Warnings, error) {
+	var parseError *reader.ParseError
+	var conversionError *reader.ConversionError
+	switch {
+	case err == nil, apierrors.IsNotFound(err):
+		return warnings, nil
+	case errors.As(err, &parseError):
+		return append(warnings, reader.Warning{Path: parseError.Path, Err: parseError.Err}), nil
+	case errors.As(err, &conversionError):
+		return append(warnings, reader.Warning{Path: conversionError.Path, Err: err}), nil
+	}
+	return warnings, err
+}
+
+func countNodes(nodes []*corev1.Node) Nodes {
+	result := Nodes{Roles: []NodeRole{}}
+	roles := make(map[string]*NodeRole)
+	for _, node := range nodes {
+		ready := false
+		for _, c := range node.Status.Conditions {
+			if c.Type == corev1.NodeReady {
+				ready = c.Status == corev1.ConditionTrue
+			}
+		}
+		result.Total++
+		if ready {
+			result.Ready++
+		}
+		for _, role := range nodeRoles(node) {
+			if _, ok := roles[role]; !ok {
+				roles[role] = &NodeRole{Role: role}
+			}
+			roles[role].Total++
+			if ready {
+				roles[role].Ready++
+			} else {
+				roles[role].NotReady++
+			}
+		}
+	}
+	for _, role := range roles {
+		result.Roles = append(result.Roles, *role)
+	}
+	slices.SortFunc(result.Roles, func(a, b NodeRole) int { return strings.Compare(a.Role, b.Role) })
+	return result
+}
+
+// the roles of a node from its node-role.kubernetes.io/<role> labels, <none> without
+func nodeRoles(node *corev1.Node) []string {
+	var roles []string
+	for label := range node.Labels {
+		if role, ok := strings.CutPrefix(label, nodeRoleLabelPrefix); ok && role != "" {
+			roles = append(roles, role)
+		}
+	}
+	if len(roles) == 0 {
+		return []string{"<none>"}
+	}
+	return roles
+}
+
+// an operator is unhealthy when it is not available or degraded
+func unhealthyOperator(co *configv1.ClusterOperator) (Operator, bool) {
+	conditions := make(map[configv1.ClusterStatusConditionType]configv1.ClusterOperatorStatusCondition)
+	for _, c := range co.Status.Conditions {
+		conditions[c.Type] = c
+	}
+	operator := Operator{
+		Name:        co.Name,
+		Available:   valueOrUnknown(string(conditions[configv1.OperatorAvailable].Status)),
+		Progressing: valueOrUnknown(string(conditions[configv1.OperatorProgressing].Status)),
+		Degraded:    valueOrUnknown(string(conditions[configv1.OperatorDegraded].Status)),
+	}
+	for _, v := range co.Status.Versions {
+		if v.Name == "operator" {
+			operator.Version = v.Version
+		}
+	}
+	switch {
+	case conditions[configv1.OperatorDegraded].Status == configv1.ConditionTrue:
+		operator.Message = conditions[configv1.OperatorDegraded].Message
+	case conditions[configv1.OperatorAvailable].Status != configv1.ConditionTrue:
+		operator.Message = conditions[configv1.OperatorAvailable].Message
+	default:
+		return operator, false
+	}
+	return operator, true
+}
+
+// a pool is unhealthy when it is degraded or has not rolled out its configuration to all machines
+func unhealthyPool(mcp *mcfgv1.MachineConfigPool) (MachineConfigPool, bool) {
+	conditions := make(map[mcfgv1.MachineConfigPoolConditionType]mcfgv1.MachineConfigPoolCondition)
+	for _, c := range mcp.Status.Conditions {
+		conditions[c.Type] = c
+	}
+	pool := MachineConfigPool{
+		Name:                 mcp.Name,
+		Updated:              valueOrUnknown(string(conditions[mcfgv1.MachineConfigPoolUpdated].Status)),
+		Updating:             valueOrUnknown(string(conditions[mcfgv1.MachineConfigPoolUpdating].Status)),
+		Degraded:             valueOrUnknown(string(conditions[mcfgv1.MachineConfigPoolDegraded].Status)),
+		MachineCount:         mcp.Status.MachineCount,
+		ReadyMachineCount:    mcp.Status.ReadyMachineCount,
+		UpdatedMachineCount:  mcp.Status.UpdatedMachineCount,
+		DegradedMachineCount: mcp.Status.DegradedMachineCount,
+	}
+	// the most specific message first
+	for _, t := range []mcfgv1.MachineConfigPoolConditionType{mcfgv1.MachineConfigPoolNodeDegraded, mcfgv1.MachineConfigPoolRenderDegraded, mcfgv1.MachineConfigPoolDegraded, mcfgv1.MachineConfigPoolUpdating} {
+		if c := conditions[t]; c.Status == corev1.ConditionTrue && c.Message != "" {
+			pool.Message = c.Message
+			break
+		}
+	}
+	unhealthy := pool.Degraded == string(corev1.ConditionTrue) || pool.Updated != string(corev1.ConditionTrue) ||
+		pool.DegradedMachineCount > 0 || pool.ReadyMachineCount < pool.MachineCount
+	return pool, unhealthy
+}
+
+func valueOrUnknown(s string) string {
+	if s == "" {
+		return "Unknown"
+	}
+	return s
+}
+
+// Print writes the summary in a human readable form, with ages relative to now
+func Print(out io.Writer, s *Summary, now time.Time) error {
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "Cluster ID:\t%s\n", helpers.ValueOrNone(s.ClusterID))
+	fmt.Fprintf(w, "Version:\t%s\n", helpers.ValueOrNone(s.Version))
+	fmt.Fprintf(w, "Channel:\t%s\n", helpers.ValueOrNone(s.Channel))
+	fmt.Fprintf(w, "Platform:\t%s\n", helpers.ValueOrNone(s.Platform))
+	fmt.Fprintf(w, "Infrastructure Name:\t%s\n", helpers.ValueOrNone(s.InfrastructureName))
+	fmt.Fprintf(w, "Control Plane Topology:\t%s\n", helpers.ValueOrNone(s.ControlPlaneTopology))
+	fmt.Fprintf(w, "API Server URL:\t%s\n", helpers.ValueOrNone(s.APIServerURL))
+	fmt.Fprintf(w, "Network Type:\t%s\n", helpers.ValueOrNone(s.NetworkType))
+	gathered := "<unknown>"
+	if s.GatherTime != nil {
+		gathered = fmt.Sprintf("%s (%s ago)", s.GatherTime.Format(time.RFC3339), duration.HumanDuration(now.Sub(*s.GatherTime)))
+	}
+	fmt.Fprintf(w, "Gathered:\t%s\n", gathered)
+
+	fmt.Fprintf(w, "\nUpdate History:\n")
+	if len(s.UpdateHistory) == 0 {
+		fmt.Fprintf(w, "  <none>\n")
+	} else {
+		fmt.Fprintf(w, "  VERSION\tSTATE\tSTARTED\tCOMPLETED\n")
+		for _, u := range s.UpdateHistory {
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", u.Version, u.State, formatTime(u.Started), formatTime(u.Completed))
+		}
+	}
+
+	fmt.Fprintf(w, "\nNodes: %d (%d ready)\n", s.Nodes.Total, s.Nodes.Ready)
+	if len(s.Nodes.Roles) > 0 {
+		fmt.Fprintf(w, "  ROLE\tTOTAL\tREADY\tNOT READY\n")
+		for _, r := range s.Nodes.Roles {
+			fmt.Fprintf(w, "  %s\t%d\t%d\t%d\n", r.Role, r.Total, r.Ready, r.NotReady)
+		}
+	}
+
+	fmt.Fprintf(w, "\nUnhealthy Cluster Operators:\n")
+	if len(s.UnhealthyOperators) == 0 {
+		fmt.Fprintf(w, "  <none>\n")
+	} else {
+		fmt.Fprintf(w, "  NAME\tVERSION\tAVAILABLE\tPROGRESSING\tDEGRADED\tMESSAGE\n")
+		for _, o := range s.UnhealthyOperators {
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\t%s\n", o.Name, o.Version, o.Available, o.Progressing, o.Degraded, firstLine(o.Message))
+		}
+	}
+
+	fmt.Fprintf(w, "\nUnhealthy MachineConfigPools:\n")
+	if len(s.UnhealthyPools) == 0 {
+		fmt.Fprintf(w, "  <none>\n")
+	} else {
+		fmt.Fprintf(w, "  NAME\tUPDATED\tUPDATING\tDEGRADED\tMACHINES\tREADY\tUPDATED\tDEGRADED\tMESSAGE\n")
+		for _, p := range s.UnhealthyPools {
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%d\t%d\t%d\t%d\t%s\n", p.Name, p.Updated, p.Updating, p.Degraded, p.MachineCount, p.ReadyMachineCount, p.UpdatedMachineCount, p.DegradedMachineCount, firstLine(p.Message))
+		}
+	}
+	return w.Flush()
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "<none>"
+	}
+	return t.Format(time.RFC3339)
+}
+
+// long, multi-line messages would break the table layout
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
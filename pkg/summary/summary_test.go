@@ -0,0 +1,146 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package summary
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bverschueren/in2un/internal/testutil"
+	"github.com/bverschueren/in2un/pkg/reader"
+)
+
+func newTestReader(t *testing.T, files map[string]string) *reader.InsightsReader {
+	ir, err := reader.NewInsightsReader(testutil.WriteArchive(t, files))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ir.Close() })
+	return ir
+}
+
+func TestSummarize(t *testing.T) {
+	ir := newTestReader(t, map[string]string{
+		"config/version.json":                   `{"apiVersion":"config.openshift.io/v1","kind":"ClusterVersion","metadata":{"name":"version"},"spec":{"clusterID":"abc","channel":"stable-4.16"},"status":{"desired":{"version":"4.16.40"},"history":[{"state":"Partial","version":"4.16.40","startedTime":"2024-10-15T10:00:00Z"},{"state":"Completed","version":"4.16.30","startedTime":"2024-09-01T10:00:00Z","completionTime":"2024-09-01T11:00:00Z"},{"state":"Completed","version":"4.16.20"}]}}`,
+		"config/infrastructure.json":            `{"apiVersion":"config.openshift.io/v1","kind":"Infrastructure","metadata":{"name":"cluster"},"status":{"infrastructureName":"abc-x7k2p","platform":"AWS","platformStatus":{"type":"AWS"},"apiServerURL":"https://api.abc.example.com:6443","controlPlaneTopology":"HighlyAvailable"}}`,
+		"config/network.json":                   `{"apiVersion":"config.openshift.io/v1","kind":"Network","metadata":{"name":"cluster"},"spec":{"networkType":"OpenShiftSDN"},"status":{"networkType":"OVNKubernetes"}}`,
+		"config/node/master-0.json":             `{"metadata":{"name":"master-0","labels":{"node-role.kubernetes.io/master":"","node-role.kubernetes.io/control-plane":""}},"status":{"conditions":[{"type":"Ready","status":"True"}]}}`,
+		"config/node/worker-0.json":             `{"metadata":{"name":"worker-0","labels":{"node-role.kubernetes.io/worker":""}},"status":{"conditions":[{"type":"Ready","status":"True"}]}}`,
+		"config/node/worker-1.json":             `{"metadata":{"name":"worker-1","labels":{"node-role.kubernetes.io/worker":""}},"status":{"conditions":[{"type":"Ready","status":"Unknown"}]}}`,
+		"config/node/infra-0.json":              `{"metadata":{"name":"infra-0"},"status":{"conditions":[{"type":"Ready","status":"False"}]}}`,
+		"config/clusteroperator/dns.json":       `{"metadata":{"name":"dns"},"status":{"conditions":[{"type":"Available","status":"True"},{"type":"Degraded","status":"False"}]}}`,
+		"config/clusteroperator/network.json":   `{"metadata":{"name":"network"},"status":{"conditions":[{"type":"Available","status":"True"},{"type":"Progressing","status":"True"},{"type":"Degraded","status":"True","message":"DaemonSet \"openshift-ovn-kubernetes/ovnkube-node\" rollout is not making progress\nPod ovnkube-node-abc is in CrashLoopBackOff"}],"versions":[{"name":"operator","version":"4.16.40"}]}}`,
+		"config/clusteroperator/ingress.json":   `{"metadata":{"name":"ingress"},"status":{"conditions":[{"type":"Available","status":"False","message":"no router pods"},{"type":"Degraded","status":"False"}]}}`,
+		"config/machineconfigpools/master.json": `{"metadata":{"name":"master"},"status":{"machineCount":3,"readyMachineCount":3,"updatedMachineCount":3,"conditions":[{"type":"Updated","status":"True"},{"type":"Degraded","status":"False"}]}}`,
+		"config/machineconfigpools/worker.json": `{"metadata":{"name":"worker"},"status":{"machineCount":2,"readyMachineCount":1,"updatedMachineCount":1,"degradedMachineCount":1,"conditions":[{"type":"Updated","status":"False"},{"type":"Updating","status":"True","message":"updating"},{"type":"Degraded","status":"True","message":"1 nodes are reporting degraded status"},{"type":"NodeDegraded","status":"True","message":"Node worker-1 is reporting: \"unexpected on-disk state\""}]}}`,
+	})
+	s, warnings, err := Summarize(ir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 0 {
+		t.Fatalf("Expected: no warnings, got: %v", warnings)
+	}
+
+	testCases := []struct {
+		name     string
+		got      interface{}
+		expected interface{}
+	}{
+		{"cluster id", s.ClusterID, "abc"},
+		{"version", s.Version, "4.16.40"},
+		{"channel", s.Channel, "stable-4.16"},
+		{"update history", len(s.UpdateHistory), 3},
+		{"last update", s.UpdateHistory[0].State + " " + s.UpdateHistory[0].Version, "Partial 4.16.40"},
+		{"completed update", s.UpdateHistory[1].Completed != nil && s.UpdateHistory[0].Completed == nil, true},
+		{"update without times", s.UpdateHistory[2], Update{Version: "4.16.20", State: "Completed"}},
+		{"platform", s.Platform, "AWS"},
+		{"infrastructure name", s.InfrastructureName, "abc-x7k2p"},
+		{"network type from status", s.NetworkType, "OVNKubernetes"},
+		{"nodes", s.Nodes.Total, 4},
+		{"ready nodes", s.Nodes.Ready, 2},
+		{"node roles", s.Nodes.Roles, []NodeRole{
+			{Role: "<none>", Total: 1, NotReady: 1},
+			{Role: "control-plane", Total: 1, Ready: 1},
+			{Role: "master", Total: 1, Ready: 1},
+			{Role: "worker", Total: 2, Ready: 1, NotReady: 1},
+		}},
+		{"unhealthy operators", len(s.UnhealthyOperators), 2},
+		{"unavailable operator", s.UnhealthyOperators[0].Name + ": " + s.UnhealthyOperators[0].Message, "ingress: no router pods"},
+		{"degraded operator", s.UnhealthyOperators[1].Name + " " + s.UnhealthyOperators[1].Version + " " + s.UnhealthyOperators[1].Degraded, "network 4.16.40 True"},
+		{"unhealthy pools", len(s.UnhealthyPools), 1},
+		{"degraded pool", s.UnhealthyPools[0].Name + ": " + s.UnhealthyPools[0].Message, "worker: Node worker-1 is reporting: \"unexpected on-disk state\""},
+		{"gather time", s.GatherTime != nil && s.GatherTime.Equal(testutil.GatherTime), true},
+	}
+	for _, tc := range testCases {
+		got, _ := json.Marshal(tc.got)
+		expected, _ := json.Marshal(tc.expected)
+		if string(got) != string(expected) {
+			t.Fatalf("%s: Expected: %s, got: %s", tc.name, expected, got)
+		}
+	}
+
+	out := &bytes.Buffer{}
+	if err := Print(out, s, testutil.GatherTime.Add(5*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"Cluster ID:              abc\n",
+		"Gathered:                2024-10-16T12:00:00Z (5h ago)\n",
+		"  4.16.40  Partial    2024-10-15T10:00:00Z  <none>\n",
+		"  4.16.20  Completed  <none>                <none>\n",
+		"Nodes: 4 (2 ready)\n",
+		"  worker         2      1      1\n",
+		"  network  4.16.40  True       True         True      DaemonSet \"openshift-ovn-kubernetes/ovnkube-node\" rollout is not making progress\n",
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Fatalf("Expected: %q, got: %s", expected, out.String())
+		}
+	}
+}
+
+func TestSummarizeEmpty(t *testing.T) {
+	ir := newTestReader(t, map[string]string{
+		"config/version.json":        `{"apiVersion":"config.openshift.io/v1","kind":"ClusterVersion","metadata":{"name":"version"}`,
+		"config/node/master-0.json":  `{"metadata":{"name":"master-0"},"spec":{"unschedulable":"yes"}}`,
+		"config/infrastructure.json": `{"apiVersion":"config.openshift.io/v1","kind":"Infrastructure","metadata":{"name":"cluster"},"status":{"platform":42}}`,
+	})
+	s, warnings, err := Summarize(ir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 3 || warnings[0].Path != "config/version.json" || warnings[1].Path != "config/infrastructure.json" || warnings[2].Path != "config/node/master-0.json" {
+		t.Fatalf("Expected: warnings for config/version.json, config/infrastructure.json and config/node/master-0.json, got: %v", warnings)
+	}
+	raw, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"clusterID":"","version":"","updateHistory":[],"platform":"","networkType":"","nodes":{"total":0,"ready":0,"roles":[]},"unhealthyOperators":[],"unhealthyMachineConfigPools":[],"gatherTime":"2024-10-16T12:00:00Z"}`
+	if string(raw) != expected {
+		t.Fatalf("Expected: %s, got: %s", expected, raw)
+	}
+	out := &bytes.Buffer{}
+	if err := Print(out, s, testutil.GatherTime); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Unhealthy Cluster Operators:\n  <none>\n") {
+		t.Fatalf("Expected: no unhealthy operators, got: %s", out.String())
+	}
+}
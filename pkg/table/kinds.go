@@ -0,0 +1,380 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package table
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bverschueren/in2un/pkg/helpers"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func init() {
+	register(schema.GroupKind{Kind: "Pod"},
+		nameColumn,
+		column{metav1.TableColumnDefinition{Name: "Ready", Type: "string"}, podReady},
+		column{metav1.TableColumnDefinition{Name: "Status", Type: "string"}, podStatus},
+		column{metav1.TableColumnDefinition{Name: "Restarts", Type: "string"}, podRestarts},
+		ageColumn,
+		stringColumn("IP", 1, "status", "podIP"),
+		stringColumn("Node", 1, "spec", "nodeName"),
+		stringColumn("Nominated Node", 1, "status", "nominatedNodeName"),
+		column{metav1.TableColumnDefinition{Name: "Readiness Gates", Type: "string", Priority: 1}, podReadinessGates},
+	)
+	register(schema.GroupKind{Kind: "Node"},
+		nameColumn,
+		column{metav1.TableColumnDefinition{Name: "Status", Type: "string"}, nodeStatus},
+		column{metav1.TableColumnDefinition{Name: "Roles", Type: "string"}, nodeRoles},
+		ageColumn,
+		stringColumn("Version", 0, "status", "nodeInfo", "kubeletVersion"),
+		column{metav1.TableColumnDefinition{Name: "Internal-IP", Type: "string", Priority: 1}, nodeAddress("InternalIP")},
+		column{metav1.TableColumnDefinition{Name: "External-IP", Type: "string", Priority: 1}, nodeAddress("ExternalIP")},
+		stringColumn("OS-Image", 1, "status", "nodeInfo", "osImage"),
+		stringColumn("Kernel-Version", 1, "status", "nodeInfo", "kernelVersion"),
+		stringColumn("Container-Runtime", 1, "status", "nodeInfo", "containerRuntimeVersion"),
+	)
+	register(schema.GroupKind{Group: "config.openshift.io", Kind: "ClusterOperator"},
+		nameColumn,
+		column{metav1.TableColumnDefinition{Name: "Version", Type: "string"}, clusterOperatorVersion},
+		conditionColumn("Available", "Available"),
+		conditionColumn("Progressing", "Progressing"),
+		conditionColumn("Degraded", "Degraded"),
+		column{metav1.TableColumnDefinition{Name: "Since", Type: "string"}, conditionSince("Available")},
+		column{metav1.TableColumnDefinition{Name: "Message", Type: "string", Priority: 1}, conditionMessage("Degraded")},
+	)
+	register(schema.GroupKind{Group: "machineconfiguration.openshift.io", Kind: "MachineConfigPool"},
+		nameColumn,
+		stringColumn("Config", 0, "status", "configuration", "name"),
+		conditionColumn("Updated", "Updated"),
+		conditionColumn("Updating", "Updating"),
+		conditionColumn("Degraded", "Degraded"),
+		integerColumn("MachineCount", 0, "status", "machineCount"),
+		integerColumn("ReadyMachineCount", 0, "status", "readyMachineCount"),
+		integerColumn("UpdatedMachineCount", 0, "status", "updatedMachineCount"),
+		integerColumn("DegradedMachineCount", 0, "status", "degradedMachineCount"),
+		ageColumn,
+	)
+	register(schema.GroupKind{Kind: "PersistentVolume"},
+		nameColumn,
+		stringColumn("Capacity", 0, "spec", "capacity", "storage"),
+		column{metav1.TableColumnDefinition{Name: "Access Modes", Type: "string"}, accessModes("spec", "accessModes")},
+		stringColumn("Reclaim Policy", 0, "spec", "persistentVolumeReclaimPolicy"),
+		stringColumn("Status", 0, "status", "phase"),
+		column{metav1.TableColumnDefinition{Name: "Claim", Type: "string"}, persistentVolumeClaim},
+		column{metav1.TableColumnDefinition{Name: "StorageClass", Type: "string"}, storageClassName},
+		column{metav1.TableColumnDefinition{Name: "Reason", Type: "string"}, func(obj *unstructured.Unstructured, _ time.Time) interface{} {
+			return helpers.StringAt(obj.Object, "status", "reason")
+		}},
+		ageColumn,
+		column{metav1.TableColumnDefinition{Name: "VolumeMode", Type: "string", Priority: 1}, volumeMode},
+	)
+	register(schema.GroupKind{Kind: "PersistentVolumeClaim"},
+		nameColumn,
+		column{metav1.TableColumnDefinition{Name: "Status", Type: "string"}, persistentVolumeClaimStatus},
+		stringColumn("Volume", 0, "spec", "volumeName"),
+		stringColumn("Capacity", 0, "status", "capacity", "storage"),
+		column{metav1.TableColumnDefinition{Name: "Access Modes", Type: "string"}, accessModes("status", "accessModes")},
+		column{metav1.TableColumnDefinition{Name: "StorageClass", Type: "string"}, storageClassName},
+		ageColumn,
+		column{metav1.TableColumnDefinition{Name: "VolumeMode", Type: "string", Priority: 1}, volumeMode},
+	)
+	register(schema.GroupKind{Group: "storage.k8s.io", Kind: "StorageClass"},
+		column{nameColumn.definition, storageClassName},
+		stringColumn("Provisioner", 0, "provisioner"),
+		column{metav1.TableColumnDefinition{Name: "ReclaimPolicy", Type: "string"}, defaultString("Delete", "reclaimPolicy")},
+		column{metav1.TableColumnDefinition{Name: "VolumeBindingMode", Type: "string"}, defaultString("Immediate", "volumeBindingMode")},
+		column{metav1.TableColumnDefinition{Name: "AllowVolumeExpansion", Type: "string"}, func(obj *unstructured.Unstructured, _ time.Time) interface{} {
+			allow, _, _ := unstructured.NestedBool(obj.Object, "allowVolumeExpansion")
+			return fmt.Sprintf("%t", allow)
+		}},
+		ageColumn,
+	)
+	register(schema.GroupKind{Kind: "Event"},
+		column{metav1.TableColumnDefinition{Name: "Last Seen", Type: "string"}, eventLastSeen},
+		stringColumn("Type", 0, "type"),
+		stringColumn("Reason", 0, "reason"),
+		column{metav1.TableColumnDefinition{Name: "Object", Type: "string"}, eventObject},
+		column{metav1.TableColumnDefinition{Name: "Message", Type: "string"}, func(obj *unstructured.Unstructured, _ time.Time) interface{} {
+			return strings.TrimSpace(helpers.StringAt(obj.Object, "message"))
+		}},
+	)
+}
+
+func podReady(obj *unstructured.Unstructured, _ time.Time) interface{} {
+	containers, _, _ := unstructured.NestedSlice(obj.Object, "spec", "containers")
+	ready := 0
+	for _, status := range helpers.MapsAt(obj.Object, "status", "containerStatuses") {
+		if isReady, _, _ := unstructured.NestedBool(status, "ready"); isReady {
+			ready++
+		}
+	}
+	return fmt.Sprintf("%d/%d", ready, len(containers))
+}
+
+// the status of a pod as shown by kubectl, e.g. Running, CrashLoopBackOff or Init:0/1
+func podStatus(obj *unstructured.Unstructured, _ time.Time) interface{} {
+	reason := helpers.StringAt(obj.Object, "status", "phase")
+	if r := helpers.StringAt(obj.Object, "status", "reason"); r != "" {
+		reason = r
+	}
+	initContainers, _, _ := unstructured.NestedSlice(obj.Object, "spec", "initContainers")
+	initializing := false
+	for i, status := range helpers.MapsAt(obj.Object, "status", "initContainerStatuses") {
+		terminated, isTerminated, _ := unstructured.NestedMap(status, "state", "terminated")
+		exitCode, _, _ := unstructured.NestedInt64(terminated, "exitCode")
+		waitingReason := helpers.StringAt(status, "state", "waiting", "reason")
+		switch {
+		case isTerminated && exitCode == 0:
+			continue
+		case isTerminated:
+			reason = "Init:" + terminatedReason(terminated)
+		case waitingReason != "" && waitingReason != "PodInitializing":
+			reason = "Init:" + waitingReason
+		default:
+			reason = fmt.Sprintf("Init:%d/%d", i, len(initContainers))
+		}
+		initializing = true
+		break
+	}
+	if !initializing {
+		hasRunning := false
+		statuses := helpers.MapsAt(obj.Object, "status", "containerStatuses")
+		for i := len(statuses) - 1; i >= 0; i-- {
+			status := statuses[i]
+			terminated, isTerminated, _ := unstructured.NestedMap(status, "state", "terminated")
+			_, isRunning, _ := unstructured.NestedMap(status, "state", "running")
+			ready, _, _ := unstructured.NestedBool(status, "ready")
+			if waitingReason := helpers.StringAt(status, "state", "waiting", "reason"); waitingReason != "" {
+				reason = waitingReason
+			} else if isTerminated {
+				reason = terminatedReason(terminated)
+			} else if isRunning && ready {
+				hasRunning = true
+			}
+		}
+		// a completed container with another one still running means the pod is running
+		if reason == "Completed" && hasRunning {
+			reason = "NotReady"
+			if helpers.StringAt(condition(obj, "Ready"), "status") == "True" {
+				reason = "Running"
+			}
+		}
+	}
+	if obj.GetDeletionTimestamp() != nil {
+		if helpers.StringAt(obj.Object, "status", "reason") == "NodeLost" {
+			return "Unknown"
+		}
+		return "Terminating"
+	}
+	return reason
+}
+
+func terminatedReason(terminated map[string]interface{}) string {
+	if reason := helpers.StringAt(terminated, "reason"); reason != "" {
+		return reason
+	}
+	if signal, _, _ := unstructured.NestedInt64(terminated, "signal"); signal != 0 {
+		return fmt.Sprintf("Signal:%d", signal)
+	}
+	exitCode, _, _ := unstructured.NestedInt64(terminated, "exitCode")
+	return fmt.Sprintf("ExitCode:%d", exitCode)
+}
+
+// the total restarts of all containers together with the time since the last restart
+func podRestarts(obj *unstructured.Unstructured, now time.Time) interface{} {
+	var restarts int64
+	var last time.Time
+	for _, status := range helpers.MapsAt(obj.Object, "status", "containerStatuses") {
+		count, _, _ := unstructured.NestedInt64(status, "restartCount")
+		restarts += count
+		if finished, err := time.Parse(time.RFC3339, helpers.StringAt(status, "lastState", "terminated", "finishedAt")); err == nil && finished.After(last) {
+			last = finished
+		}
+	}
+	if restarts > 0 && !last.IsZero() {
+		return fmt.Sprintf("%d (%s ago)", restarts, age(metav1.NewTime(last), now))
+	}
+	return formatInt(restarts)
+}
+
+func podReadinessGates(obj *unstructured.Unstructured, _ time.Time) interface{} {
+	gates := helpers.MapsAt(obj.Object, "spec", "readinessGates")
+	if len(gates) == 0 {
+		return "<none>"
+	}
+	ready := 0
+	for _, gate := range gates {
+		if helpers.StringAt(condition(obj, helpers.StringAt(gate, "conditionType")), "status") == "True" {
+			ready++
+		}
+	}
+	return fmt.Sprintf("%d/%d", ready, len(gates))
+}
+
+func nodeStatus(obj *unstructured.Unstructured, _ time.Time) interface{} {
+	var status []string
+	switch helpers.StringAt(condition(obj, "Ready"), "status") {
+	case "True":
+		status = append(status, "Ready")
+	case "False":
+		status = append(status, "NotReady")
+	default:
+		status = append(status, "Unknown")
+	}
+	if unschedulable, _, _ := unstructured.NestedBool(obj.Object, "spec", "unschedulable"); unschedulable {
+		status = append(status, "SchedulingDisabled")
+	}
+	return strings.Join(status, ",")
+}
+
+// roles from the node-role.kubernetes.io/<role> and kubernetes.io/role labels
+func nodeRoles(obj *unstructured.Unstructured, _ time.Time) interface{} {
+	var roles []string
+	for label, value := range obj.GetLabels() {
+		switch {
+		case strings.HasPrefix(label, "node-role.kubernetes.io/"):
+			if role := strings.TrimPrefix(label, "node-role.kubernetes.io/"); role != "" {
+				roles = append(roles, role)
+			}
+		case label == "kubernetes.io/role" && value != "":
+			roles = append(roles, value)
+		}
+	}
+	sort.Strings(roles)
+	return joinOrNone(roles)
+}
+
+func nodeAddress(addressType string) func(*unstructured.Unstructured, time.Time) interface{} {
+	return func(obj *unstructured.Unstructured, _ time.Time) interface{} {
+		for _, address := range helpers.MapsAt(obj.Object, "status", "addresses") {
+			if helpers.StringAt(address, "type") == addressType {
+				return helpers.StringAt(address, "address")
+			}
+		}
+		return "<none>"
+	}
+}
+
+func clusterOperatorVersion(obj *unstructured.Unstructured, _ time.Time) interface{} {
+	for _, version := range helpers.MapsAt(obj.Object, "status", "versions") {
+		if helpers.StringAt(version, "name") == "operator" {
+			return helpers.StringAt(version, "version")
+		}
+	}
+	return ""
+}
+
+func conditionSince(conditionType string) func(*unstructured.Unstructured, time.Time) interface{} {
+	return func(obj *unstructured.Unstructured, now time.Time) interface{} {
+		since, err := time.Parse(time.RFC3339, helpers.StringAt(condition(obj, conditionType), "lastTransitionTime"))
+		if err != nil {
+			return "<unknown>"
+		}
+		return age(metav1.NewTime(since), now)
+	}
+}
+
+func conditionMessage(conditionType string) func(*unstructured.Unstructured, time.Time) interface{} {
+	return func(obj *unstructured.Unstructured, _ time.Time) interface{} {
+		return helpers.StringAt(condition(obj, conditionType), "message")
+	}
+}
+
+var accessModeAbbreviations = map[string]string{
+	"ReadWriteOnce":    "RWO",
+	"ReadOnlyMany":     "ROX",
+	"ReadWriteMany":    "RWX",
+	"ReadWriteOncePod": "RWOP",
+}
+
+func accessModes(fields ...string) func(*unstructured.Unstructured, time.Time) interface{} {
+	return func(obj *unstructured.Unstructured, _ time.Time) interface{} {
+		modes, _, _ := unstructured.NestedStringSlice(obj.Object, fields...)
+		var result []string
+		for _, mode := range modes {
+			if abbreviation, ok := accessModeAbbreviations[mode]; ok {
+				mode = abbreviation
+			}
+			result = append(result, mode)
+		}
+		return strings.Join(result, ",")
+	}
+}
+
+func persistentVolumeClaim(obj *unstructured.Unstructured, _ time.Time) interface{} {
+	claim, found, _ := unstructured.NestedMap(obj.Object, "spec", "claimRef")
+	if !found {
+		return ""
+	}
+	return helpers.StringAt(claim, "namespace") + "/" + helpers.StringAt(claim, "name")
+}
+
+func persistentVolumeClaimStatus(obj *unstructured.Unstructured, _ time.Time) interface{} {
+	if obj.GetDeletionTimestamp() != nil {
+		return "Terminating"
+	}
+	return helpers.StringAt(obj.Object, "status", "phase")
+}
+
+// the storage class of a PersistentVolume(Claim) or, for a StorageClass, its name marked when it is the default
+func storageClassName(obj *unstructured.Unstructured, _ time.Time) interface{} {
+	if obj.GetKind() == "StorageClass" {
+		annotations := obj.GetAnnotations()
+		if annotations["storageclass.kubernetes.io/is-default-class"] == "true" || annotations["storageclass.beta.kubernetes.io/is-default-class"] == "true" {
+			return obj.GetName() + " (default)"
+		}
+		return obj.GetName()
+	}
+	if name := helpers.StringAt(obj.Object, "spec", "storageClassName"); name != "" {
+		return name
+	}
+	return obj.GetAnnotations()["volume.beta.kubernetes.io/storage-class"]
+}
+
+func volumeMode(obj *unstructured.Unstructured, now time.Time) interface{} {
+	return defaultString("Filesystem", "spec", "volumeMode")(obj, now)
+}
+
+func defaultString(defaultValue string, fields ...string) func(*unstructured.Unstructured, time.Time) interface{} {
+	return func(obj *unstructured.Unstructured, _ time.Time) interface{} {
+		if s := helpers.StringAt(obj.Object, fields...); s != "" {
+			return s
+		}
+		return defaultValue
+	}
+}
+
+// time since an event was last seen, e.g. '5m (x3 over 1h)' for repeated events
+func eventLastSeen(obj *unstructured.Unstructured, now time.Time) interface{} {
+	return helpers.EventAge(obj, now)
+}
+
+// the object an event is about as kind/name, compacted events in insights archives do not record it
+func eventObject(obj *unstructured.Unstructured, _ time.Time) interface{} {
+	kind, name := helpers.StringAt(obj.Object, "involvedObject", "kind"), helpers.StringAt(obj.Object, "involvedObject", "name")
+	if name == "" {
+		return "<unknown>"
+	}
+	if kind == "" {
+		return name
+	}
+	return strings.ToLower(kind) + "/" + name
+}
@@ -0,0 +1,134 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package table
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bverschueren/in2un/pkg/helpers"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+// column computes a table cell from the unstructured content of an object, relative to now
+type column struct {
+	definition metav1.TableColumnDefinition
+	cell       func(obj *unstructured.Unstructured, now time.Time) interface{}
+}
+
+var (
+	nameColumn = column{
+		definition: metav1.TableColumnDefinition{Name: "Name", Type: "string", Format: "name", Description: "Name of the resource"},
+		cell:       func(obj *unstructured.Unstructured, _ time.Time) interface{} { return obj.GetName() },
+	}
+	ageColumn = column{
+		definition: metav1.TableColumnDefinition{Name: "Age", Type: "string", Description: "Time since the resource was created"},
+		cell: func(obj *unstructured.Unstructured, now time.Time) interface{} {
+			return age(obj.GetCreationTimestamp(), now)
+		},
+	}
+)
+
+// columns per kind, following the server-side printing of kubectl and oc.
+// Kinds not listed get the name and age columns only.
+var columns = map[schema.GroupKind][]column{}
+
+func register(kind schema.GroupKind, c ...column) {
+	columns[kind] = c
+}
+
+// ToTable converts objects of a single kind into a table with the columns defined for their kind
+func ToTable(items []unstructured.Unstructured, now time.Time) *metav1.Table {
+	cols := []column{nameColumn, ageColumn}
+	if len(items) > 0 {
+		if c, ok := columns[items[0].GroupVersionKind().GroupKind()]; ok {
+			cols = c
+		}
+	}
+	table := &metav1.Table{}
+	for _, c := range cols {
+		table.ColumnDefinitions = append(table.ColumnDefinitions, c.definition)
+	}
+	for i := range items {
+		row := metav1.TableRow{Object: runtime.RawExtension{Object: &items[i]}}
+		for _, c := range cols {
+			row.Cells = append(row.Cells, c.cell(&items[i], now))
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return table
+}
+
+// a column showing the string found at fields of the object
+func stringColumn(name string, priority int32, fields ...string) column {
+	return column{
+		definition: metav1.TableColumnDefinition{Name: name, Type: "string", Priority: priority},
+		cell: func(obj *unstructured.Unstructured, _ time.Time) interface{} {
+			return helpers.ValueOrNone(helpers.StringAt(obj.Object, fields...))
+		},
+	}
+}
+
+// a column showing the integer found at fields of the object
+func integerColumn(name string, priority int32, fields ...string) column {
+	return column{
+		definition: metav1.TableColumnDefinition{Name: name, Type: "integer", Priority: priority},
+		cell: func(obj *unstructured.Unstructured, _ time.Time) interface{} {
+			n, _, _ := unstructured.NestedInt64(obj.Object, fields...)
+			return n
+		},
+	}
+}
+
+// a column showing the status of a condition of the object
+func conditionColumn(name, conditionType string) column {
+	return column{
+		definition: metav1.TableColumnDefinition{Name: name, Type: "string"},
+		cell: func(obj *unstructured.Unstructured, _ time.Time) interface{} {
+			return helpers.ValueOrNone(helpers.StringAt(condition(obj, conditionType), "status"))
+		},
+	}
+}
+
+func age(t metav1.Time, now time.Time) string {
+	if t.IsZero() {
+		return "<unknown>"
+	}
+	return duration.HumanDuration(now.Sub(t.Time))
+}
+
+// the status condition of the given type, nil when not found
+func condition(obj *unstructured.Unstructured, conditionType string) map[string]interface{} {
+	for _, c := range helpers.MapsAt(obj.Object, "status", "conditions") {
+		if helpers.StringAt(c, "type") == conditionType {
+			return c
+		}
+	}
+	return nil
+}
+
+func joinOrNone(s []string) string {
+	return helpers.ValueOrNone(strings.Join(s, ","))
+}
+
+func formatInt(n int64) string {
+	return fmt.Sprintf("%d", n)
+}
@@ -0,0 +1,124 @@
+/*
+Copyright © 2024 Bram Verschueren <bverschueren@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package table
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bverschueren/in2un/internal/testutil"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestToTable(t *testing.T) {
+	now := time.Date(2024, 10, 16, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name            string
+		raw             string
+		expectedColumns []string
+		expectedCells   []interface{}
+	}{
+		{
+			name:            "repeated event",
+			raw:             `{"apiVersion":"v1","kind":"Event","metadata":{"name":"e","namespace":"ns"},"type":"Warning","reason":"BackOff","count":3,"firstTimestamp":"2024-10-16T11:00:00Z","lastTimestamp":"2024-10-16T11:55:00Z","involvedObject":{"kind":"Pod","name":"etcd"},"message":"Back-off restarting failed container\n"}`,
+			expectedColumns: []string{"Last Seen", "Type", "Reason", "Object", "Message"},
+			expectedCells:   []interface{}{"5m (x3 over 60m)", "Warning", "BackOff", "pod/etcd", "Back-off restarting failed container"},
+		},
+		{
+			name:            "compacted event",
+			raw:             `{"apiVersion":"v1","kind":"Event","metadata":{"name":"e","namespace":"ns"},"type":"Normal","reason":"Started","count":1,"lastTimestamp":"2024-10-16T10:00:00Z","message":"Started container etcd"}`,
+			expectedColumns: []string{"Last Seen", "Type", "Reason", "Object", "Message"},
+			expectedCells:   []interface{}{"120m", "Normal", "Started", "<unknown>", "Started container etcd"},
+		},
+		{
+			name:            "unknown kind",
+			raw:             `{"apiVersion":"v1","kind":"Fake","metadata":{"name":"fake","creationTimestamp":"2024-10-15T12:00:00Z"}}`,
+			expectedColumns: []string{"Name", "Age"},
+			expectedCells:   []interface{}{"fake", "24h"},
+		},
+		{
+			name:            "running pod",
+			raw:             `{"apiVersion":"v1","kind":"Pod","metadata":{"name":"pod","creationTimestamp":"2024-10-14T12:00:00Z"},"spec":{"containers":[{"name":"c"}]},"status":{"phase":"Running","podIP":"10.0.0.1","containerStatuses":[{"name":"c","ready":true,"restartCount":0,"state":{"running":{}}}]}}`,
+			expectedColumns: []string{"Name", "Ready", "Status", "Restarts", "Age", "IP", "Node", "Nominated Node", "Readiness Gates"},
+			expectedCells:   []interface{}{"pod", "1/1", "Running", "0", "2d", "10.0.0.1", "<none>", "<none>", "<none>"},
+		},
+		{
+			name:            "crashlooping pod",
+			raw:             `{"apiVersion":"v1","kind":"Pod","metadata":{"name":"pod"},"spec":{"containers":[{"name":"c"}]},"status":{"phase":"Running","containerStatuses":[{"name":"c","ready":false,"restartCount":3,"state":{"waiting":{"reason":"CrashLoopBackOff"}},"lastState":{"terminated":{"exitCode":1,"finishedAt":"2024-10-16T11:55:00Z"}}}]}}`,
+			expectedColumns: []string{"Name", "Ready", "Status", "Restarts", "Age", "IP", "Node", "Nominated Node", "Readiness Gates"},
+			expectedCells:   []interface{}{"pod", "0/1", "CrashLoopBackOff", "3 (5m ago)", "<unknown>", "<none>", "<none>", "<none>", "<none>"},
+		},
+		{
+			name:            "initializing pod",
+			raw:             `{"apiVersion":"v1","kind":"Pod","metadata":{"name":"pod"},"spec":{"initContainers":[{"name":"i1"},{"name":"i2"}],"containers":[{"name":"c"}]},"status":{"phase":"Pending","initContainerStatuses":[{"name":"i1","state":{"terminated":{"exitCode":0}}},{"name":"i2","state":{"running":{}}}]}}`,
+			expectedColumns: []string{"Name", "Ready", "Status", "Restarts", "Age", "IP", "Node", "Nominated Node", "Readiness Gates"},
+			expectedCells:   []interface{}{"pod", "0/1", "Init:1/2", "0", "<unknown>", "<none>", "<none>", "<none>", "<none>"},
+		},
+		{
+			name:            "node",
+			raw:             `{"apiVersion":"v1","kind":"Node","metadata":{"name":"master-0","labels":{"node-role.kubernetes.io/master":"","node-role.kubernetes.io/control-plane":""}},"status":{"conditions":[{"type":"Ready","status":"False"}],"addresses":[{"type":"InternalIP","address":"10.0.0.5"}],"nodeInfo":{"kubeletVersion":"v1.29.5"}}}`,
+			expectedColumns: []string{"Name", "Status", "Roles", "Age", "Version", "Internal-IP", "External-IP", "OS-Image", "Kernel-Version", "Container-Runtime"},
+			expectedCells:   []interface{}{"master-0", "NotReady", "control-plane,master", "<unknown>", "v1.29.5", "10.0.0.5", "<none>", "<none>", "<none>", "<none>"},
+		},
+		{
+			name:            "clusteroperator",
+			raw:             `{"apiVersion":"config.openshift.io/v1","kind":"ClusterOperator","metadata":{"name":"network"},"status":{"versions":[{"name":"operator","version":"4.16.40"}],"conditions":[{"type":"Available","status":"True","lastTransitionTime":"2024-10-16T10:00:00Z"},{"type":"Progressing","status":"False"},{"type":"Degraded","status":"True","message":"broken"}]}}`,
+			expectedColumns: []string{"Name", "Version", "Available", "Progressing", "Degraded", "Since", "Message"},
+			expectedCells:   []interface{}{"network", "4.16.40", "True", "False", "True", "120m", "broken"},
+		},
+		{
+			name:            "machineconfigpool",
+			raw:             `{"apiVersion":"machineconfiguration.openshift.io/v1","kind":"MachineConfigPool","metadata":{"name":"master"},"status":{"configuration":{"name":"rendered-master"},"machineCount":3,"readyMachineCount":2,"updatedMachineCount":2,"degradedMachineCount":1,"conditions":[{"type":"Updated","status":"False"},{"type":"Updating","status":"True"},{"type":"Degraded","status":"True"}]}}`,
+			expectedColumns: []string{"Name", "Config", "Updated", "Updating", "Degraded", "MachineCount", "ReadyMachineCount", "UpdatedMachineCount", "DegradedMachineCount", "Age"},
+			expectedCells:   []interface{}{"master", "rendered-master", "False", "True", "True", int64(3), int64(2), int64(2), int64(1), "<unknown>"},
+		},
+		{
+			name:            "persistentvolume",
+			raw:             `{"apiVersion":"v1","kind":"PersistentVolume","metadata":{"name":"pv"},"spec":{"capacity":{"storage":"10Gi"},"accessModes":["ReadWriteOnce","ReadOnlyMany"],"persistentVolumeReclaimPolicy":"Delete","claimRef":{"namespace":"ns","name":"data"},"storageClassName":"gp3"},"status":{"phase":"Bound"}}`,
+			expectedColumns: []string{"Name", "Capacity", "Access Modes", "Reclaim Policy", "Status", "Claim", "StorageClass", "Reason", "Age", "VolumeMode"},
+			expectedCells:   []interface{}{"pv", "10Gi", "RWO,ROX", "Delete", "Bound", "ns/data", "gp3", "", "<unknown>", "Filesystem"},
+		},
+		{
+			name:            "persistentvolumeclaim",
+			raw:             `{"apiVersion":"v1","kind":"PersistentVolumeClaim","metadata":{"name":"data","namespace":"ns"},"spec":{"volumeName":"pv","storageClassName":"gp3","volumeMode":"Block"},"status":{"phase":"Bound","capacity":{"storage":"10Gi"},"accessModes":["ReadWriteOnce"]}}`,
+			expectedColumns: []string{"Name", "Status", "Volume", "Capacity", "Access Modes", "StorageClass", "Age", "VolumeMode"},
+			expectedCells:   []interface{}{"data", "Bound", "pv", "10Gi", "RWO", "gp3", "<unknown>", "Block"},
+		},
+		{
+			name:            "default storageclass",
+			raw:             `{"apiVersion":"storage.k8s.io/v1","kind":"StorageClass","metadata":{"name":"gp3","annotations":{"storageclass.kubernetes.io/is-default-class":"true"}},"provisioner":"ebs.csi.aws.com","volumeBindingMode":"WaitForFirstConsumer"}`,
+			expectedColumns: []string{"Name", "Provisioner", "ReclaimPolicy", "VolumeBindingMode", "AllowVolumeExpansion", "Age"},
+			expectedCells:   []interface{}{"gp3 (default)", "ebs.csi.aws.com", "Delete", "WaitForFirstConsumer", "false", "<unknown>"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ToTable([]unstructured.Unstructured{testutil.Unstructured(t, tc.raw)}, now)
+			var columns []string
+			for _, c := range got.ColumnDefinitions {
+				columns = append(columns, c.Name)
+			}
+			if !reflect.DeepEqual(columns, tc.expectedColumns) {
+				t.Fatalf("Expected: %v, got: %v", tc.expectedColumns, columns)
+			}
+			if len(got.Rows) != 1 || !reflect.DeepEqual(got.Rows[0].Cells, tc.expectedCells) {
+				t.Fatalf("Expected: %v, got: %v", tc.expectedCells, got.Rows)
+			}
+		})
+	}
+}